@@ -0,0 +1,97 @@
+package abstractions
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"abstractions/internal/sink"
+)
+
+func Run(r io.Reader) error {
+	buf := new(bytes.Buffer)
+	writeAll(buf, "a")
+	writeAll(buf, "b")
+
+	copyTo(buf, "c")
+	copyTo(os.Stdout, "d")
+
+	notify(emailNotifier{addr: "x@example.com"}, "e")
+
+	dynamic(r)
+	dynamic(strings.NewReader("f"))
+
+	apply(value)
+
+	maybe(nil)
+	maybe(buf)
+
+	bw := bufio.NewWriter(os.Stdout)
+	fmt.Fprint(bw, buf.String())
+	if err := sink.Flush(bw); err != nil {
+		return err
+	}
+	return sink.Close(os.Stdout)
+}
+
+// writeAll is only ever given a *bytes.Buffer.
+func writeAll(w io.Writer, s string) {
+	io.WriteString(w, s)
+}
+
+// copyTo is given two different types.
+func copyTo(w io.Writer, s string) {
+	io.WriteString(w, s)
+}
+
+type notifier interface {
+	Notify(msg string) error
+}
+
+type emailNotifier struct {
+	addr string
+}
+
+func (e emailNotifier) Notify(msg string) error {
+	_, err := fmt.Printf("to %s: %s\n", e.addr, msg)
+	return err
+}
+
+// notify is only ever given an emailNotifier,
+// but the tests have a mock notifier.
+func notify(n notifier, msg string) error {
+	return n.Notify(msg)
+}
+
+// dynamic is given a value of an interface type.
+func dynamic(r io.Reader) {
+	io.Copy(io.Discard, r)
+}
+
+// value is used as a function value.
+func value(w io.Writer) {
+	io.WriteString(w, "g")
+}
+
+func apply(f func(io.Writer)) {
+	f(os.Stdout)
+}
+
+// maybe is only ever given a *bytes.Buffer, or nil.
+func maybe(w io.Writer) {
+	if w != nil {
+		io.WriteString(w, "h")
+	}
+}
+
+// Exported could be called from anywhere.
+func Exported(w io.Writer) {
+	io.WriteString(w, "i")
+}
+
+func callExported() {
+	Exported(os.Stdout)
+}
@@ -0,0 +1,25 @@
+package abstractions
+
+import "testing"
+
+type fakeNotifier struct {
+	msgs []string
+}
+
+func (f *fakeNotifier) Notify(msg string) error {
+	f.msgs = append(f.msgs, msg)
+	return nil
+}
+
+var _ notifier = (*fakeNotifier)(nil)
+
+// fakeCloser is a mock for sink.Close.
+type fakeCloser struct{}
+
+func (fakeCloser) Close() error { return nil }
+
+func TestRun(t *testing.T) {
+	if err := Run(nil); err == nil {
+		t.Log("ok")
+	}
+}
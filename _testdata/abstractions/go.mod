@@ -0,0 +1,3 @@
+module abstractions
+
+go 1.19
@@ -0,0 +1,25 @@
+package sink
+
+import "bufio"
+
+type flusher interface {
+	Flush() error
+}
+
+// Flush is exported from an internal package,
+// and only ever given a *bufio.Writer.
+func Flush(f flusher) error {
+	return f.Flush()
+}
+
+var _ flusher = (*bufio.Writer)(nil)
+
+type closer interface {
+	Close() error
+}
+
+// Close is only ever given an *os.File,
+// but the tests of another package have a mock closer.
+func Close(c closer) error {
+	return c.Close()
+}
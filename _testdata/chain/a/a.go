@@ -0,0 +1,15 @@
+// Package a sorts before package b but imports it,
+// so it must be checked after it.
+package a
+
+import (
+	"os"
+
+	"m/chain/b"
+)
+
+// {"f": {"Close": "func() error", "Read": "func([]byte) (int, error)"}}
+// {"f": "io.ReadCloser"}
+func A(f *os.File) ([]byte, error) {
+	return b.B(f)
+}
@@ -0,0 +1,13 @@
+package b
+
+import (
+	"os"
+
+	"m"
+)
+
+// {"f": {"Close": "func() error", "Read": "func([]byte) (int, error)"}}
+// {"f": "io.ReadCloser"}
+func B(f *os.File) ([]byte, error) {
+	return m.F7(f)
+}
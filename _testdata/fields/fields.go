@@ -0,0 +1,70 @@
+package fields
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+)
+
+type Store struct {
+	db   *sql.DB
+	log  *os.File
+	out  io.ReadWriter
+	raw  *os.File
+	tmp  *os.File
+	name string
+	buf  bytes.Buffer
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db, log: os.Stderr, out: os.Stdout}
+}
+
+func (s *Store) Get(ctx context.Context, id int) error {
+	rows, err := s.db.QueryContext(ctx, "SELECT name FROM t WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+func (s *Store) Put(ctx context.Context, id int) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO t (id) VALUES (?)", id)
+	if err != nil {
+		w := s.log
+		fmt.Fprintln(w, err)
+	}
+	return err
+}
+
+func (s *Store) Report() {
+	fmt.Fprintf(s.out, "store %s\n", s.name)
+}
+
+func (s *Store) Log(msg string) {
+	s.buf.WriteString(msg)
+}
+
+func (s *Store) Raw() *os.File {
+	return s.raw
+}
+
+func (s *Store) Cleanup() error {
+	return closeFile(s.tmp)
+}
+
+func closeFile(f *os.File) error {
+	return f.Close()
+}
+
+type Box[T any] struct {
+	f *os.File
+	v T
+}
+
+func (b Box[T]) Close() error {
+	return b.f.Close()
+}
@@ -2,6 +2,7 @@ package m
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -29,9 +30,10 @@ func F3(lf *io.LimitedReader) ([]byte, int64, error) {
 	return b, lf.N, err
 }
 
-// {}
+// {"f": {"Read": "func([]byte) (int, error)"}}
+// {"f": "io.Reader"}
 func F4(f *os.File) ([]byte, error) {
-	var f2 *os.File = f // Some day perhaps decouple will be clever enough to know that f and f2 can both be io.Readers.
+	var f2 *os.File = f // f2 is an alias of f, and gets the same new type.
 	return io.ReadAll(f2)
 }
 
@@ -41,7 +43,8 @@ func F5(r *os.File) ([]byte, error) {
 	return io.ReadAll(f2)
 }
 
-// {}
+// {"f": {"Close": "func() error", "Read": "func([]byte) (int, error)"}}
+// {"f": "io.ReadCloser"}
 func F6(f *os.File) ([]byte, error) {
 	return F7(f)
 }
@@ -261,7 +264,8 @@ func F31(r *os.File) io.Reader {
 // {}
 func F32(_ io.Reader) {}
 
-// {}
+// {"ch": {"Read": "func([]byte) (int, error)"}}
+// {"ch": "io.Reader"}
 func F33(ch <-chan *os.File) ([]byte, error) {
 	r := <-ch
 	return io.ReadAll(r)
@@ -282,7 +286,8 @@ func F35(x interface {
 	x.foo()
 }
 
-// {}
+// {"inps": {"Read": "func([]byte) (int, error)"}}
+// {"inps": "io.Reader"}
 func F36(w io.Writer, inps []*os.File) error {
 	for _, inp := range inps {
 		if _, err := io.Copy(w, inp); err != nil {
@@ -400,3 +405,320 @@ func F49(n int) int {
 	n++
 	return n
 }
+
+// {"f": {"Read": "func([]byte) (int, error)"}}
+// {"f": "io.Reader"}
+func F50(f *os.File) ([]byte, error) {
+	return F51(f) // F51 is checked after F50, and depends on F52, checked after that.
+}
+
+// {"f": {"Read": "func([]byte) (int, error)"}}
+// {"f": "io.Reader"}
+func F51(f *os.File) ([]byte, error) {
+	return F52(f)
+}
+
+// {"r": {"Read": "func([]byte) (int, error)"}}
+// {"r": "io.Reader"}
+func F52(r *os.File) ([]byte, error) {
+	return io.ReadAll(r)
+}
+
+// {}
+func F53(lf *io.LimitedReader) ([]byte, error) {
+	b, _, err := F3(lf)
+	return b, err
+}
+
+type f54 func() int
+
+// {}
+func F54(fn func() int) int {
+	return f54(fn)() // conversion to a function type
+}
+
+var f55 *os.File
+
+// {}
+func F55(f *os.File) {
+	f55 = f
+}
+
+// {"f": {"Close": "func() error", "Read": "func([]byte) (int, error)"}}
+// {"f": "io.ReadCloser"}
+func F56(f *os.File) ([]byte, error) {
+	g := f
+	var h *os.File
+	h = g
+	defer h.Close()
+	f = h
+	if r := f; true {
+		return io.ReadAll(r)
+	}
+	return nil, nil
+}
+
+// {}
+func F57(f *os.File) *os.File {
+	g := f
+	return g
+}
+
+type fileReader interface {
+	ReadFile(*os.File) ([]byte, error)
+}
+
+type t58 struct{}
+
+var _ fileReader = t58{}
+
+// {}
+func (t58) ReadFile(f *os.File) ([]byte, error) {
+	return io.ReadAll(f) // f could be an io.Reader, but then t58 would not be a fileReader.
+}
+
+// {"f": {"Read": "func([]byte) (int, error)"}}
+// {"f": "io.Reader"}
+func (t58) ReadOther(f *os.File) ([]byte, error) {
+	return io.ReadAll(f)
+}
+
+type fileCloser interface {
+	CloseFile(*os.File) error
+}
+
+type t59 struct{}
+
+// {}
+func (*t59) CloseFile(f *os.File) error {
+	return f.Close()
+}
+
+// {}
+func F59() fileCloser {
+	return &t59{}
+}
+
+// {}
+func F60(f *os.File) ([]byte, error) {
+	return io.ReadAll(f)
+}
+
+// {}
+func F61() func(*os.File) ([]byte, error) {
+	return F60
+}
+
+type f62 func(*os.File) error
+
+// {}
+func F62(f *os.File) error {
+	return f.Close()
+}
+
+var _ = f62(F62)
+
+// {"r": {"Read": "func([]byte) (int, error)"}}
+// {"r": "io.Reader"}
+func F63(w io.Writer, r *os.File) (int64, error) {
+	return io.Copy(w, r) // io.Copy checks whether r is an io.WriterTo.
+}
+
+// {"rc": {"Read": "func([]byte) (int, error)"}}
+// {"rc": "io.Reader"}
+func F64(rc io.ReadCloser) ([]byte, error) {
+	if c, ok := rc.(io.Closer); ok {
+		defer c.Close()
+	}
+	return io.ReadAll(rc)
+}
+
+// {}
+func F65(f *os.File) error {
+	p := &f // After decoupling, p would be a pointer to an interface.
+	return (*p).Close()
+}
+
+// {}
+func F66(f *os.File) error {
+	fmt.Println(&f) // After decoupling, fmt would see a pointer to an interface.
+	return f.Close()
+}
+
+// {}
+func F67(f *os.File) error {
+	reopen(&f)
+	return f.Close()
+}
+
+// {}
+func reopen(fp **os.File) {
+	*fp, _ = os.Open((*fp).Name())
+}
+
+// {}
+func readAllGeneric[R io.Reader](r R) ([]byte, error) {
+	return io.ReadAll(r)
+}
+
+// {}
+func pickGeneric[R io.Reader](first bool, r1, r2 R) R {
+	if first {
+		return r1
+	}
+	return r2
+}
+
+// {"f": {"Read": "func([]byte) (int, error)"}}
+// {"f": "io.Reader"}
+func F68(f *os.File) ([]byte, error) {
+	return readAllGeneric(f) // R is inferred as whatever type f has.
+}
+
+// {}
+func F69(f *os.File) ([]byte, error) {
+	return readAllGeneric[*os.File](f)
+}
+
+// {}
+func F70(f *os.File) io.Reader {
+	return pickGeneric(true, f, os.Stdin) // R must be the same for both arguments.
+}
+
+// {}
+func readCloseGeneric[RC io.ReadCloser](rc RC) ([]byte, error) {
+	return io.ReadAll(rc) // Needs only io.Reader.
+}
+
+// {}
+func closeGeneric[C interface {
+	io.Reader
+	Close() error
+}](c C) error {
+	return c.Close()
+}
+
+// {}
+func countGeneric[S fmt.Stringer](s1, s2 S) int {
+	return 2
+}
+
+// {}
+func stringGeneric[S fmt.Stringer](s S) string {
+	var s2 S = s // S is mentioned in the body.
+	return s2.String()
+}
+
+// {"rs": {"Read": "func([]byte) (int, error)"}}
+// {"rs": "io.Reader"}
+func F71(rs ...*os.File) (n int, err error) {
+	for i := range rs {
+		data, err := io.ReadAll(rs[i])
+		if err != nil {
+			return n, err
+		}
+		n += len(data)
+	}
+	return n, nil
+}
+
+// {"m": {"Close": "func() error"}}
+// {"m": "io.Closer"}
+func F72(m map[string]*os.File, name string) error {
+	if f, ok := m[name]; ok {
+		return f.Close()
+	}
+	return nil
+}
+
+// {"ch": {"Read": "func([]byte) (int, error)", "Close": "func() error"}}
+// {"ch": "io.ReadCloser"}
+func F73(ch chan *os.File) ([]byte, error) {
+	for {
+		select {
+		case f, ok := <-ch:
+			if !ok {
+				return nil, nil
+			}
+			defer f.Close()
+			return io.ReadAll(f)
+		}
+	}
+}
+
+// {}
+func F74(fs []*os.File) []*os.File {
+	return append(fs, os.Stdin) // The container itself is used.
+}
+
+// {}
+func F75(fs []*os.File) ([]byte, error) {
+	fs[0] = os.Stdin // Elements are stored.
+	return io.ReadAll(fs[1])
+}
+
+// {"fs": {"Name": "func() string"}}
+func F76(fs []*os.File) string {
+	if len(fs) == 0 || fs == nil {
+		return ""
+	}
+	return fs[0].Name()
+}
+
+// {}
+func F77(a, b int) bool {
+	return a < b
+}
+
+// {}
+func F78(f *os.File) ([]byte, error) {
+	g := os.Stdin // g's type comes from os.Stdin, not f, so g cannot be an alias of f.
+	g = f
+	return io.ReadAll(g)
+}
+
+// {}
+func F79(f *os.File) ([]byte, error) {
+	var g = os.Stdin
+	g = f
+	return io.ReadAll(g)
+}
+
+// {"f": {"Read": "func([]byte) (int, error)"}}
+// {"f": "io.Reader"}
+func F80(f *os.File) ([]byte, error) {
+	var g = f // g's type comes from f's.
+	return io.ReadAll(g)
+}
+
+// {}
+func F81(f *os.File, data []byte) error {
+	f.Close()
+	return json.Unmarshal(data, &f) // After decoupling, json would decode into an interface.
+}
+
+// {"rc": {"Close": "func() error"}}
+// {"rc": "io.Closer"}
+func F82(rc io.ReadCloser) error {
+	fmt.Println(&rc) // Already a pointer to an interface.
+	return rc.Close()
+}
+
+// {"f": {"Read": "func([]byte) (int, error)"}}
+// {"f": "io.Reader"}
+func F83(f *os.File) ([]byte, error) {
+	var g *os.File = os.Stdin // The new type replaces g's explicit one.
+	g = f
+	return io.ReadAll(g)
+}
+
+// {"f": {"Close": "func() error"}}
+// {"f": "io.Closer"}
+func F84(f *os.File) error {
+	return f.Close()
+}
+
+// {}
+func F85() error {
+	return F84(nil) // F84's type parameter could not be inferred from nil.
+}
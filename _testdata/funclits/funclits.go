@@ -0,0 +1,63 @@
+package funclits
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+func Direct(f *os.File, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func(f *os.File) {
+		defer wg.Done()
+		f.Close()
+	}(f)
+}
+
+func Local(f *os.File) ([]byte, error) {
+	read := func(r *os.File) ([]byte, error) {
+		return io.ReadAll(r)
+	}
+	return read(f)
+}
+
+func Var(f *os.File) error {
+	var write = func(w *os.File, s string) error {
+		_, err := io.WriteString(w, s)
+		return err
+	}
+	return write(f, "hello")
+}
+
+func Passed(f *os.File) {
+	closer := func(c *os.File) { c.Close() }
+	apply(closer, f)
+}
+
+func apply(fn func(*os.File), f *os.File) {
+	fn(f)
+}
+
+func Reassigned(f *os.File) {
+	closer := func(c *os.File) { c.Close() }
+	closer = func(c *os.File) { c.Sync() }
+	closer(f)
+}
+
+func Typed(f *os.File) {
+	var closer func(*os.File) = func(c *os.File) { c.Close() }
+	closer(f)
+}
+
+func Concrete(f *os.File) {
+	func(g *os.File) {
+		apply(func(*os.File) {}, g)
+	}(f)
+}
+
+func Generic[T io.Closer](x T) {
+	func(t T, c *os.File) {
+		t.Close()
+		c.Close()
+	}(x, os.Stdin)
+}
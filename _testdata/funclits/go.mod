@@ -0,0 +1,3 @@
+module funclits
+
+go 1.19
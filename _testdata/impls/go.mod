@@ -0,0 +1,3 @@
+module impls
+
+go 1.19
@@ -0,0 +1,105 @@
+package impls
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Store is implemented by pgStore and memStore,
+// which together need only ExecContext and QueryRowContext from tx.
+type Store interface {
+	Put(ctx context.Context, tx *sql.Tx, key, val string) error
+	Get(ctx context.Context, tx *sql.Tx, key string) (string, error)
+}
+
+type pgStore struct{}
+
+func (pgStore) Put(ctx context.Context, tx *sql.Tx, key, val string) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO kv VALUES ($1, $2)", key, val)
+	return err
+}
+
+func (pgStore) Get(ctx context.Context, tx *sql.Tx, key string) (string, error) {
+	var val string
+	err := tx.QueryRowContext(ctx, "SELECT val FROM kv WHERE key = $1", key).Scan(&val)
+	return val, err
+}
+
+type memStore struct {
+	m map[string]string
+}
+
+func (s *memStore) Put(_ context.Context, tx *sql.Tx, key, val string) error {
+	s.m[key] = val
+	return nil
+}
+
+func (s *memStore) Get(ctx context.Context, tx *sql.Tx, key string) (string, error) {
+	if _, err := tx.ExecContext(ctx, "SELECT 1"); err != nil {
+		return "", err
+	}
+	return s.m[key], nil
+}
+
+// Logger's implementations need different methods of f.
+type Logger interface {
+	Log(f *os.File, msg string)
+}
+
+type plainLogger struct{}
+
+func (plainLogger) Log(f *os.File, msg string) {
+	fmt.Fprintln(f, msg)
+}
+
+type syncLogger struct{}
+
+func (syncLogger) Log(f *os.File, msg string) {
+	io.WriteString(f, msg)
+	f.Sync()
+}
+
+// Opener's implementation returns its parameter.
+type Opener interface {
+	Open(*os.File) *os.File
+}
+
+type passOpener struct{}
+
+func (passOpener) Open(f *os.File) *os.File {
+	return f
+}
+
+// Syncer's implementation is also used as otherSyncer,
+// which fixes its signature.
+type Syncer interface {
+	SyncTo(f *os.File) error
+}
+
+type otherSyncer interface {
+	SyncTo(*os.File) error
+}
+
+type diskSyncer struct{}
+
+func (diskSyncer) SyncTo(f *os.File) error {
+	return f.Sync()
+}
+
+// Unimplemented has no implementations.
+type Unimplemented interface {
+	Do(*os.File)
+}
+
+var (
+	_ Store       = pgStore{}
+	_ Store       = &memStore{}
+	_ Logger      = plainLogger{}
+	_ Logger      = syncLogger{}
+	_ Opener      = passOpener{}
+	_ Syncer      = diskSyncer{}
+	_ otherSyncer = diskSyncer{}
+)
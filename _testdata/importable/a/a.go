@@ -0,0 +1,17 @@
+// Package a imports b,
+// so b cannot use its interface types.
+package a
+
+import (
+	"bufio"
+
+	"importable/b"
+)
+
+type Flusher interface {
+	Flush() error
+}
+
+func Run(w *bufio.Writer) error {
+	return b.Do(w)
+}
@@ -0,0 +1,16 @@
+package b
+
+import (
+	"bufio"
+	"os"
+)
+
+// Do could take an a.Flusher, but that would be an import cycle.
+func Do(w *bufio.Writer) error {
+	return w.Flush()
+}
+
+// Sync could take a main.Syncer, but a main package cannot be imported.
+func Sync(f *os.File) error {
+	return f.Sync()
+}
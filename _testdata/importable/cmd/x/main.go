@@ -0,0 +1,15 @@
+package main
+
+import (
+	"os"
+
+	"importable/b"
+)
+
+type Syncer interface {
+	Sync() error
+}
+
+func main() {
+	b.Sync(os.Stdout)
+}
@@ -0,0 +1,3 @@
+module importable
+
+go 1.19
@@ -0,0 +1,3 @@
+module iters
+
+go 1.23
@@ -0,0 +1,96 @@
+// Package iters has parameters that could be iterators.
+// Its go.mod file declares Go 1.23,
+// which is needed for range-over-func.
+package iters
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Join could take an iter.Seq[string].
+func Join(strs []string) string {
+	var buf strings.Builder
+	for _, s := range strs {
+		buf.WriteString(s)
+	}
+	return buf.String()
+}
+
+// Number could take an iter.Seq2[int, string].
+func Number(w io.Writer, lines []string) {
+	for i, line := range lines {
+		fmt.Fprintf(w, "%d: %s\n", i+1, line)
+	}
+}
+
+// Total could take an iter.Seq[int] (of the map's values).
+func Total(m map[string]int) int {
+	var total int
+	for _, n := range m {
+		total += n
+	}
+	return total
+}
+
+// Names could take an iter.Seq[string] (of the map's keys).
+func Names(m map[string]int) []string {
+	var result []string
+	for k, _ := range m {
+		result = append(result, k)
+	}
+	return result
+}
+
+// Show could take an iter.Seq2[string, int].
+func Show(w io.Writer, m map[string]int) {
+	for k, v := range m {
+		fmt.Fprintf(w, "%s=%d\n", k, v)
+	}
+	for k := range m {
+		fmt.Fprintln(w, k)
+	}
+}
+
+// Count uses len, so it needs a slice.
+func Count(strs []string) int {
+	var n int
+	for range strs {
+		n++
+	}
+	return n + len(strs)
+}
+
+// First indexes strs, so it needs a slice.
+func First(strs []string) string {
+	for _, s := range strs {
+		if s != "" {
+			return s
+		}
+	}
+	return strs[0]
+}
+
+// Readers' elements could be io.Reader,
+// which takes precedence.
+func Readers(rs []*strings.Reader) ([][]byte, error) {
+	var result [][]byte
+	for _, r := range rs {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, data)
+	}
+	return result, nil
+}
+
+// Callback is used as a function value,
+// so its signature is fixed.
+func Callback(strs []string) {
+	for range strs {
+	}
+}
+
+var _ func([]string) = Callback
@@ -0,0 +1,3 @@
+module segregation
+
+go 1.19
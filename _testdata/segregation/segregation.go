@@ -0,0 +1,84 @@
+package segregation
+
+import (
+	"fmt"
+	"io"
+)
+
+// Store is a "god interface."
+// Its Stats method is never called,
+// and Close is needed only by the io.Closer it is passed as.
+type Store interface {
+	Get(key string) (string, error)
+	Put(key, val string) error
+	Delete(key string) error
+	Keys() []string
+	Close() error
+	Stats() string
+}
+
+// Lookup needs only Get.
+func Lookup(s Store, key string) string {
+	val, _ := s.Get(key)
+	return val
+}
+
+// Copy needs Get, Put, and Keys.
+func Copy(dst, src Store) error {
+	for _, key := range src.Keys() {
+		val, err := src.Get(key)
+		if err != nil {
+			return err
+		}
+		if err := dst.Put(key, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Server needs only Get and Put.
+type Server struct {
+	store Store
+	name  string
+}
+
+func (s *Server) Handle(key, val string) error {
+	if val == "" {
+		_, err := s.store.Get(key)
+		return err
+	}
+	return s.store.Put(key, val)
+}
+
+var defaultStore Store
+
+// Purge needs only Delete.
+func Purge(key string) error {
+	return defaultStore.Delete(key)
+}
+
+func shutdown(s Store) {
+	closeAll(s)
+}
+
+func closeAll(closers ...io.Closer) {
+	for _, c := range closers {
+		c.Close()
+	}
+}
+
+// Logger is needed in full by its only consumer.
+type Logger interface {
+	Logf(format string, args ...any)
+	Flush()
+}
+
+func report(l Logger, err error) {
+	l.Logf("error: %s", err)
+	l.Flush()
+}
+
+func describe(s Store) {
+	fmt.Println(s)
+}
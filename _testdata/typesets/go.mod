@@ -0,0 +1,3 @@
+module typesets
+
+go 1.21
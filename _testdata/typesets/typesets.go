@@ -0,0 +1,87 @@
+package typesets
+
+import (
+	"fmt"
+	"math"
+)
+
+func Abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func Max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func Equal(a, b string) bool {
+	return a == b
+}
+
+func Concat(a, b string) string {
+	return a + b
+}
+
+func Half(x float64) float64 {
+	return x * 0.5
+}
+
+func IsEven(n int) bool {
+	return n%2 == 0
+}
+
+func SumSquares(x, y float64) float64 {
+	var s float64
+	s += x * x
+	s += y * y
+	return s
+}
+
+func Scale(x float64, n int) float64 {
+	return x * float64(n)
+}
+
+func Describe(n int) string {
+	return fmt.Sprint(n + 1)
+}
+
+func Shift(x uint, n int) uint {
+	return x << n
+}
+
+func Get(strs []string, i int) string {
+	return strs[i]
+}
+
+// Sum combines n with the loop variable i,
+// whose type comes from the constant 0.
+func Sum(n int) int {
+	total := 0
+	for i := 0; i < n; i++ {
+		total += i
+	}
+	return total
+}
+
+func Sqrt(x float64) float64 {
+	return math.Sqrt(x)
+}
+
+func Large(x int) bool {
+	return x > 1000
+}
+
+func Identity(x int) int {
+	return x
+}
+
+func Callback(a, b int) bool {
+	return a < b
+}
+
+var _ = Callback
@@ -0,0 +1,52 @@
+package vars
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+var client = &http.Client{}
+
+var sb strings.Builder
+
+var (
+	logger       = log.New(os.Stderr, "vars: ", 0)
+	out, errs    *os.File = os.Stdout, os.Stderr
+	rw           io.ReadWriter
+	debug        bool
+	defaultLimit = 10
+)
+
+func Fetch(url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	logger.Printf("fetching %s", url)
+	return client.Do(req)
+}
+
+func Report(msg string) {
+	if debug {
+		fmt.Fprintln(out, msg)
+	}
+	w := rw
+	w.Write([]byte(msg))
+}
+
+func Record(msg string) {
+	sb.WriteString(msg)
+}
+
+func Fail(err error) *os.File {
+	logger.Print(err)
+	return errs
+}
+
+func SetOutput(f *os.File) {
+	out = f
+}
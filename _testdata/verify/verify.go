@@ -0,0 +1,11 @@
+package verify
+
+import (
+	"os"
+	"strings"
+)
+
+func Base(f *os.File) string {
+	name := f.Name()
+	return name[strings.LastIndex(name, "/")+1:]
+}
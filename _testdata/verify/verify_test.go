@@ -0,0 +1,15 @@
+package verify
+
+import (
+	"os"
+	"testing"
+)
+
+// Only the tests use Base as a value of this type.
+var base func(*os.File) string = Base
+
+func TestBase(t *testing.T) {
+	if got := base(os.Stdin); got != "stdin" {
+		t.Errorf("got %s, want stdin", got)
+	}
+}
@@ -0,0 +1,282 @@
+package decouple
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/bobg/go-generics/v3/set"
+	"golang.org/x/tools/go/packages"
+)
+
+// Abstraction is an interface-typed parameter of a function
+// that is only ever given values of one concrete type.
+// See Checker.CheckAbstractions.
+type Abstraction struct {
+	// F is the declaration of the function.
+	F *ast.FuncDecl
+
+	// P is the package in which F is declared.
+	P *packages.Package
+
+	// Name is the name of the parameter in F.
+	Name *ast.Ident
+
+	// Concrete is the type of every argument passed for the parameter.
+	Concrete types.Type
+
+	// Calls are the positions of the calls to F,
+	// sorted.
+	Calls []token.Position
+}
+
+// Pos computes the filename and offset
+// of the parameter name in a.
+func (a Abstraction) Pos() token.Position {
+	return a.P.Fset.Position(a.Name.Pos())
+}
+
+// CheckAbstractions looks for interface-typed parameters
+// that are not paying for themselves:
+// ones that are only ever given values of a single concrete type,
+// and whose interface types are not implemented by any type in the tests
+// (which would be a mock).
+// The result is a list of Abstractions,
+// sorted by position.
+//
+// Only the parameters of functions whose callers can all be seen are checked:
+// those of unexported functions,
+// and of exported functions in internal packages.
+// Methods are not checked,
+// since they may be called through interfaces,
+// nor are functions used other than in calls,
+// e.g. as function values.
+// A call passing nil for the parameter does not count against it;
+// one passing a value of some interface type does,
+// since the value's dynamic type is not known.
+// The parameters of test functions are not checked,
+// and tests are seen only if the Checker's packages include them,
+// as with NewCheckerFromDirWithTests.
+func (ch Checker) CheckAbstractions() (_ []Abstraction, err error) {
+	defer recoverDerr(&err)
+
+	type candidate struct {
+		Abstraction
+		params   []*types.Var          // the parameter in each variant of its package
+		types    map[string]types.Type // types of the arguments passed for the parameter, by name
+		calls    set.Of[token.Position]
+		rejected bool
+	}
+
+	var (
+		candidates = make(map[token.Position]*candidate)      // by position of the parameter name
+		byFunc     = make(map[*types.Func]map[int]*candidate) // functions -> parameter indexes -> candidates
+	)
+	for _, pkg := range ch.pkgs {
+		for _, file := range pkg.Syntax {
+			if isTestFile(pkg, file.Pos()) {
+				continue
+			}
+			for _, decl := range file.Decls {
+				fndecl, ok := decl.(*ast.FuncDecl)
+				if !ok || fndecl.Recv != nil || fndecl.Body == nil {
+					continue
+				}
+				if ast.IsExported(fndecl.Name.Name) && !isInternal(pkg.PkgPath) {
+					continue
+				}
+				fn, ok := pkg.TypesInfo.Defs[fndecl.Name].(*types.Func)
+				if !ok {
+					continue
+				}
+				params := fn.Type().(*types.Signature).Params()
+
+				var i int
+				for _, field := range fndecl.Type.Params.List {
+					if len(field.Names) == 0 {
+						i++
+						continue
+					}
+					for _, name := range field.Names {
+						param := params.At(i)
+						i++
+						if name.Name == "_" {
+							continue
+						}
+						intf := getType[*types.Interface](param.Type())
+						if intf == nil || intf.NumMethods() == 0 {
+							continue
+						}
+						pos := pkg.Fset.Position(name.Pos())
+						c, ok := candidates[pos]
+						if !ok {
+							c = &candidate{
+								Abstraction: Abstraction{F: fndecl, P: pkg, Name: name},
+								types:       make(map[string]types.Type),
+								calls:       set.New[token.Position](),
+							}
+							candidates[pos] = c
+						} else if isTestVariant(c.P) && !isTestVariant(pkg) {
+							c.F, c.P, c.Name = fndecl, pkg, name
+						}
+						c.params = append(c.params, param)
+						if byFunc[fn] == nil {
+							byFunc[fn] = make(map[int]*candidate)
+						}
+						byFunc[fn][i-1] = c
+					}
+				}
+			}
+		}
+	}
+
+	for _, pkg := range ch.pkgs {
+		for _, file := range pkg.Syntax {
+			callees := set.New[*ast.Ident]()
+			ast.Inspect(file, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.CallExpr:
+					id := calleeIdent(n.Fun)
+					if id == nil {
+						return true
+					}
+					fn, ok := pkg.TypesInfo.Uses[id].(*types.Func)
+					if !ok {
+						return true
+					}
+					cands := byFunc[fn.Origin()]
+					if len(cands) == 0 {
+						return true
+					}
+					callees.Add(id)
+
+					var argTypes []types.Type
+					if len(n.Args) == 1 {
+						if tuple, ok := pkg.TypesInfo.TypeOf(n.Args[0]).(*types.Tuple); ok {
+							for i := 0; i < tuple.Len(); i++ {
+								argTypes = append(argTypes, tuple.At(i).Type())
+							}
+						}
+					}
+					if argTypes == nil {
+						for _, arg := range n.Args {
+							argTypes = append(argTypes, pkg.TypesInfo.TypeOf(arg))
+						}
+					}
+
+					pos := pkg.Fset.Position(n.Pos())
+					for i, c := range cands {
+						if i >= len(argTypes) || argTypes[i] == nil {
+							continue
+						}
+						c.calls.Add(pos)
+						switch typ := argTypes[i]; {
+						case types.Identical(typ, types.Typ[types.UntypedNil]):
+						case types.IsInterface(typ):
+							c.rejected = true
+						default:
+							c.types[types.TypeString(typ, nil)] = typ
+						}
+					}
+
+				case *ast.Ident:
+					if callees.Has(n) {
+						return true
+					}
+					if fn, ok := pkg.TypesInfo.Uses[n].(*types.Func); ok {
+						for _, c := range byFunc[fn.Origin()] {
+							c.rejected = true
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	// Types declared in tests that implement the interfaces are mocks,
+	// including in the tests of other packages,
+	// as with the callers of an internal package.
+	for _, pkg := range ch.pkgs {
+		for _, file := range pkg.Syntax {
+			if !isTestFile(pkg, file.Pos()) {
+				continue
+			}
+			ast.Inspect(file, func(n ast.Node) bool {
+				tspec, ok := n.(*ast.TypeSpec)
+				if !ok {
+					return true
+				}
+				tn, ok := pkg.TypesInfo.Defs[tspec.Name].(*types.TypeName)
+				if !ok || types.IsInterface(tn.Type()) {
+					return true
+				}
+				for _, c := range candidates {
+					if c.rejected {
+						continue
+					}
+					for _, param := range c.params {
+						intf := getType[*types.Interface](param.Type())
+						if types.Implements(tn.Type(), intf) || types.Implements(types.NewPointer(tn.Type()), intf) {
+							c.rejected = true
+							break
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	var result []Abstraction
+	for _, c := range candidates {
+		if c.rejected || len(c.types) != 1 {
+			continue
+		}
+		a := c.Abstraction
+		for _, typ := range c.types {
+			a.Concrete = typ
+		}
+		a.Calls = c.calls.Slice()
+		sort.Slice(a.Calls, func(i, j int) bool {
+			return PositionLess(a.Calls[i], a.Calls[j])
+		})
+		result = append(result, a)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return PositionLess(result[i].Pos(), result[j].Pos())
+	})
+
+	return result, nil
+}
+
+// calleeIdent finds the identifier naming the function in fun,
+// the function expression of a call,
+// as in f(x), pkg.f(x), or f[T](x).
+func calleeIdent(fun ast.Expr) *ast.Ident {
+	switch fun := ast.Unparen(fun).(type) {
+	case *ast.Ident:
+		return fun
+	case *ast.SelectorExpr:
+		return fun.Sel
+	case *ast.IndexExpr:
+		return calleeIdent(fun.X)
+	case *ast.IndexListExpr:
+		return calleeIdent(fun.X)
+	}
+	return nil
+}
+
+// isTestFile tells whether pos is in a test file of pkg.
+func isTestFile(pkg *packages.Package, pos token.Pos) bool {
+	return strings.HasSuffix(pkg.Fset.Position(pos).Filename, "_test.go")
+}
+
+// isTestVariant tells whether pkg is a variant of a package
+// that includes its test files,
+// as loaded with Tests set in the packages.Config.
+func isTestVariant(pkg *packages.Package) bool {
+	return strings.Contains(pkg.ID, " [")
+}
@@ -0,0 +1,64 @@
+package decouple
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCheckAbstractions(t *testing.T) {
+	cases := []struct {
+		tests bool
+		want  []string // "func.param: type", sorted
+	}{{
+		tests: true,
+		want: []string{
+			"Flush.f: *bufio.Writer",
+			"maybe.w: *bytes.Buffer",
+			"writeAll.w: *bytes.Buffer",
+		},
+	}, {
+		// Without the tests, the mocks are not seen.
+		tests: false,
+		want: []string{
+			"Close.c: *os.File",
+			"Flush.f: *bufio.Writer",
+			"maybe.w: *bytes.Buffer",
+			"notify.n: abstractions.emailNotifier",
+			"writeAll.w: *bytes.Buffer",
+		},
+	}}
+
+	for _, tc := range cases {
+		name := "without tests"
+		if tc.tests {
+			name = "with tests"
+		}
+		t.Run(name, func(t *testing.T) {
+			load := NewCheckerFromDir
+			if tc.tests {
+				load = NewCheckerFromDirWithTests
+			}
+			checker, err := load("_testdata/abstractions")
+			if err != nil {
+				t.Fatal(err)
+			}
+			abstractions, err := checker.CheckAbstractions()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, a := range abstractions {
+				got = append(got, a.F.Name.Name+"."+a.Name.Name+": "+a.Concrete.String())
+				if len(a.Calls) == 0 {
+					t.Errorf("no calls for %s.%s", a.F.Name.Name, a.Name.Name)
+				}
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
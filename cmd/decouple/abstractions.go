@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"io"
+
+	"github.com/bobg/decouple"
+)
+
+// showAbstractions reports the results of decouple.Checker.CheckAbstractions
+// in the format selected by opts.
+func showAbstractions(w io.Writer, abstractions []decouple.Abstraction, opts options) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	for _, a := range abstractions {
+		var (
+			p        = a.Pos()
+			concrete = types.TypeString(a.Concrete, types.RelativeTo(a.P.Types))
+		)
+
+		if opts.doJSON {
+			ja := jabstraction{
+				PackageName: a.P.Name,
+				FileName:    p.Filename,
+				Line:        p.Line,
+				Column:      p.Column,
+				FuncName:    a.F.Name.Name,
+				ParamName:   a.Name.Name,
+				Concrete:    concrete,
+			}
+			for _, call := range a.Calls {
+				ja.Calls = append(ja.Calls, jposition{
+					FileName: call.Filename,
+					Line:     call.Line,
+					Column:   call.Column,
+				})
+			}
+			if err := enc.Encode(ja); err != nil {
+				return err
+			}
+			continue
+		}
+
+		plural := "s"
+		if len(a.Calls) == 1 {
+			plural = ""
+		}
+		fmt.Fprintf(w, "%s: %s\n    %s: only ever %s (%d call%s)\n", p, a.F.Name.Name, a.Name.Name, concrete, len(a.Calls), plural)
+		if opts.uses {
+			for _, call := range a.Calls {
+				fmt.Fprintf(w, "        called at %s\n", call)
+			}
+		}
+	}
+
+	return nil
+}
+
+type jabstraction struct {
+	PackageName  string
+	FileName     string
+	Line, Column int
+	FuncName     string
+	ParamName    string
+
+	// Concrete is the type of every argument passed for the parameter.
+	Concrete string
+
+	Calls []jposition
+}
+
+type jposition struct {
+	FileName     string
+	Line, Column int
+}
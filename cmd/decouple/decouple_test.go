@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -13,7 +14,7 @@ import (
 
 func TestRunJSON(t *testing.T) {
 	buf := new(bytes.Buffer)
-	if err := run(buf, false, true, []string{"../.."}); err != nil {
+	if err := run(buf, options{doJSON: true}, []string{"../.."}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -27,20 +28,79 @@ func TestRunJSON(t *testing.T) {
 			t.Fatal(err)
 		}
 		val.FileName = filepath.Base(val.FileName)
+		for _, jp := range val.Params {
+			for _, uses := range jp.Uses {
+				for i := range uses {
+					uses[i].FileName = filepath.Base(uses[i].FileName)
+				}
+			}
+			for i := range jp.Upgrades {
+				jp.Upgrades[i].FileName = filepath.Base(jp.Upgrades[i].FileName)
+			}
+		}
 		got = append(got, val)
 	}
 
 	want := []jtuple{{
 		PackageName: "main",
 		FileName:    "main.go",
-		Line:        100,
+		Line:        348,
 		Column:      6,
 		FuncName:    "showJSON",
 		Params: []jparam{{
 			Name: "checker",
 			Methods: []string{
 				"NameForMethods",
+				"TypeParamNames",
+				"TypeSetNames",
+			},
+			Uses: map[string][]juse{
+				"NameForMethods": {{
+					FileName: "main.go",
+					Line:     375,
+					Column:   27,
+				}, {
+					FileName: "main.go",
+					Line:     418,
+					Column:   34,
+				}},
+				"TypeParamNames": {{
+					FileName: "main.go",
+					Line:     364,
+					Column:   22,
+				}},
+				"TypeSetNames": {{
+					FileName: "main.go",
+					Line:     423,
+					Column:   22,
+				}},
+			},
+		}},
+	}, {
+		PackageName: "decouple",
+		FileName:    "ssa.go",
+		Line:        317,
+		Column:      23,
+		FuncName:    "instr",
+		Params: []jparam{{
+			Name: "instr",
+			Methods: []string{
+				"Pos",
 			},
+			Uses: map[string][]juse{
+				"Pos": {{
+					Via:      "a.reject",
+					FileName: "ssa.go",
+					Line:     414,
+					Column:   18,
+				}},
+			},
+			Upgrades: []jupgrade{{
+				Interface: "golang.org/x/tools/go/ssa.CallInstruction",
+				FileName:  "ssa.go",
+				Line:      322,
+				Column:    7,
+			}},
 		}},
 	}}
 
@@ -51,7 +111,7 @@ func TestRunJSON(t *testing.T) {
 
 func TestRunPlain(t *testing.T) {
 	buf := new(bytes.Buffer)
-	if err := run(buf, false, false, []string{"../.."}); err != nil {
+	if err := run(buf, options{}, []string{"../.."}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -60,16 +120,393 @@ func TestRunPlain(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if len(lines) != 2 {
-		t.Fatalf("got %d lines, want 2", len(lines))
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines, want 5", len(lines))
 	}
 	if !strings.HasSuffix(lines[0], ": showJSON") {
 		t.Fatalf(`line 1 is "%s", want something ending in ": showJSON"`, lines[0])
 	}
 
 	lines[1] = strings.TrimSpace(lines[1])
-	const want = "checker: [NameForMethods]"
+	const want = "checker: [NameForMethods TypeParamNames TypeSetNames]"
 	if lines[1] != want {
 		t.Fatalf(`line 2 is "%s", want "%s"`, lines[1], want)
 	}
+	if !strings.HasSuffix(lines[2], ": instr") {
+		t.Fatalf(`line 3 is "%s", want something ending in ": instr"`, lines[2])
+	}
+	if !strings.HasPrefix(strings.TrimSpace(lines[4]), "narrowing loses optional interface") {
+		t.Fatalf(`line 5 is "%s", want a lost-upgrade note`, lines[4])
+	}
+}
+
+func TestRunElems(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := run(buf, options{style: "both"}, []string{"../../_testdata"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"    inps: []io.Reader or [INP io.Reader] []INP\n        callers must convert their arguments element by element\n",
+		"    rs: ...io.Reader or [R io.Reader] ...R\n        callers passing a slice with ... must convert it element by element\n",
+		"    ch: chan io.ReadCloser or [CH io.ReadCloser] chan CH\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+}
+
+func TestRunIters(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := run(buf, options{}, []string{"../../_testdata/iters"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"    strs: iter.Seq[string]\n        callers can convert their arguments with slices.Values\n",
+		"    m: iter.Seq2[string, int]\n        callers can convert their arguments with maps.All\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+}
+
+func TestRunTypeSets(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := run(buf, options{typesets: true}, []string{"../../_testdata/typesets"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"    a, b: [T cmp.Ordered]\n        the result is also T\n",
+		"    x: [T ~float32 | ~float64]\n        the result is also T\n",
+		"    n: [T2 ~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr]\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+
+	buf.Reset()
+	if err := run(buf, options{}, []string{"../../_testdata/typesets"}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() > 0 {
+		t.Errorf("got output without -typesets:\n%s", buf)
+	}
+}
+
+func TestRunFix(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"go.mod", "foo.go"} {
+		data, err := os.ReadFile(filepath.Join("../../_testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	orig, err := os.ReadFile(filepath.Join(dir, "foo.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := run(buf, options{diff: true}, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := os.ReadFile(filepath.Join(dir, "foo.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(orig, after) {
+		t.Fatal("-diff changed foo.go")
+	}
+
+	diff := buf.String()
+	for _, want := range []string{
+		"-func F1(r *os.File, n int) ([]byte, error) {\n+func F1(r io.Reader, n int) ([]byte, error) {\n",
+		"-func F7(rc *os.File) ([]byte, error) {\n+func F7(rc io.ReadCloser) ([]byte, error) {\n",
+		"-func F4(f *os.File) ([]byte, error) {\n-\tvar f2 *os.File = f // f2 is an alias of f, and gets the same new type.\n+func F4(f io.Reader) ([]byte, error) {\n+\tvar f2 io.Reader = f // f2 is an alias of f, and gets the same new type.\n",
+		"-func F42(ctx context.Context, f *os.File, ch <-chan struct{}) (string, error) {\n+func F42(ctx interface {\n",
+		"-func F36(w io.Writer, inps []*os.File) error {\n+func F36(w io.Writer, inps []io.Reader) error {\n",
+		"-func F72(m map[string]*os.File, name string) error {\n+func F72(m map[string]io.Closer, name string) error {\n",
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff does not contain %q", want)
+		}
+	}
+
+	buf.Reset()
+	if err := run(buf, options{fix: true}, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err = os.ReadFile(filepath.Join(dir, "foo.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(orig, after) {
+		t.Fatal("-fix did not change foo.go")
+	}
+
+	// The rewritten package must still type-check.
+	buf.Reset()
+	if err := run(buf, options{}, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunFixGeneric(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"go.mod", "foo.go"} {
+		data, err := os.ReadFile(filepath.Join("../../_testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := run(buf, options{diff: true, style: "generic"}, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	diff := buf.String()
+	for _, want := range []string{
+		"-func F1(r *os.File, n int) ([]byte, error) {\n+func F1[R io.Reader](r R, n int) ([]byte, error) {\n",
+		"-func F4(f *os.File) ([]byte, error) {\n-\tvar f2 *os.File = f // f2 is an alias of f, and gets the same new type.\n+func F4[F io.Reader](f F) ([]byte, error) {\n+\tvar f2 F = f // f2 is an alias of f, and gets the same new type.\n",
+
+		// Values of type-parameter type can't be switched on, so F11 gets an interface instead.
+		"-func F11(r *os.File) ([]byte, error) {\n+func F11(r io.Reader) ([]byte, error) {\n",
+
+		// The alias g is initialized with a value that would not be assignable to a type parameter.
+		"-func F83(f *os.File) ([]byte, error) {\n-\tvar g *os.File = os.Stdin // The new type replaces g's explicit one.\n+func F83(f io.Reader) ([]byte, error) {\n+\tvar g io.Reader = os.Stdin // The new type replaces g's explicit one.\n",
+
+		// F85 calls F84(nil), which could not infer a type parameter.
+		"-func F84(f *os.File) error {\n+func F84(f io.Closer) error {\n",
+
+		// Methods can't have type parameters.
+		"-func (t58) ReadOther(f *os.File) ([]byte, error) {\n+func (t58) ReadOther(f io.Reader) ([]byte, error) {\n",
+
+		// The type parameter is for one element.
+		"-func F71(rs ...*os.File) (n int, err error) {\n+func F71[R io.Reader](rs ...R) (n int, err error) {\n",
+		"-func F33(ch <-chan *os.File) ([]byte, error) {\n+func F33[CH io.Reader](ch <-chan CH) ([]byte, error) {\n",
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff does not contain %q", want)
+		}
+	}
+
+	buf.Reset()
+	if err := run(buf, options{fix: true, style: "generic"}, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The rewritten package must still type-check.
+	buf.Reset()
+	if err := run(buf, options{}, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := run(buf, options{fix: true, style: "both"}, []string{dir}); err == nil {
+		t.Error("got no error for -fix with -style=both")
+	}
+}
+
+func TestRunFixImportable(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"go.mod", "a/a.go", "b/b.go", "cmd/x/main.go"} {
+		data, err := os.ReadFile(filepath.Join("../../_testdata/importable", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := run(buf, options{diff: true}, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	diff := buf.String()
+	for _, want := range []string{
+		// Package a imports b.
+		"-func Do(w *bufio.Writer) error {\n+func Do(w interface{ Flush() error }) error {\n",
+
+		// A main package can't be imported.
+		"-func Sync(f *os.File) error {\n+func Sync(f interface{ Sync() error }) error {\n",
+
+		// But a can use its own interface.
+		"-func Run(w *bufio.Writer) error {\n+func Run(w Flusher) error {\n",
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff does not contain %q", want)
+		}
+	}
+
+	buf.Reset()
+	if err := run(buf, options{fix: true}, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The rewritten packages must still type-check.
+	buf.Reset()
+	if err := run(buf, options{}, []string{dir}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunWhy(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := run(buf, options{why: true}, []string{"../../_testdata"}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"    lf: not decoupled: field access (.N) at ",
+		"    r: io.Reader\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+}
+
+func TestRunFields(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := run(buf, options{fields: true, why: true}, []string{"../../_testdata/fields"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		": type Store\n    buf: not decoupled: zero value of bytes.Buffer is usable\n    db: [ExecContext QueryContext]\n    log: io.Writer\n    name: not decoupled: no methods used\n    out: io.Writer\n    raw: not decoupled: returned as concrete type at ",
+		": type Box\n    f: io.Closer\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+
+	if err := run(buf, options{fields: true, fix: true}, []string{"../../_testdata/fields"}); err == nil {
+		t.Error("got no error for -fields with -fix")
+	}
+}
+
+func TestRunVars(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := run(buf, options{vars: true}, []string{"../../_testdata/vars"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		": var client\n    client: [Do]\n",
+		": var out, errs\n    out: io.Writer\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if strings.Contains(out, "var debug") {
+		t.Errorf("got report for ineligible variable without -why:\n%s", out)
+	}
+}
+
+func TestRunFuncLits(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := run(buf, options{funclits: true}, []string{"../../_testdata/funclits"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		":11:5: function literal in Direct\n    f: io.Closer\n",
+		":18:10: function literal in Local\n    r: io.Reader\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+}
+
+func TestRunImpls(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := run(buf, options{impls: true}, []string{"../../_testdata/impls"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		": Store.Put\n    tx: [ExecContext]\n    implemented by pgStore.Put at ",
+		"    implemented by (*memStore).Put at ",
+		": Logger.Log\n    f: [Sync Write]\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if strings.Contains(out, "Opener.Open") {
+		t.Errorf("got report for ineligible method without -why:\n%s", out)
+	}
+}
+
+func TestRunSegregation(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := run(buf, options{segregation: true}, []string{"../../_testdata/segregation"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		": interface Store (6 methods)\n    never called: [Stats]\n",
+		"    param Lookup.s: [Get] (at ",
+		"    field Server.store: [Get Put] (at ",
+		"    var defaultStore: [Delete] (at ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if strings.Contains(out, "interface Logger") {
+		t.Errorf("got report for fully used interface without -why:\n%s", out)
+	}
+}
+
+func TestRunAbstractions(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := run(buf, options{abstractions: true}, []string{"../../_testdata/abstractions"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		": writeAll\n    w: only ever *bytes.Buffer (2 calls)\n",
+		": Flush\n    f: only ever *bufio.Writer (1 call)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	for _, unwanted := range []string{": notify\n", ": copyTo\n", ": Exported\n"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("output contains %q:\n%s", unwanted, out)
+		}
+	}
 }
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// unifiedDiff writes to w a unified diff between a and b,
+// the old and new contents of the named file.
+func unifiedDiff(w io.Writer, filename string, a, b []byte) error {
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	const context = 3
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "--- %s\n+++ %s\n", filename, filename)
+
+	// Find the runs of changes,
+	// then gather them into hunks with up to `context` lines of unchanged text around each one,
+	// merging hunks that would overlap.
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start := max(0, i-context)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			// How much unchanged text follows?
+			j := end
+			for j < len(ops) && ops[j].kind == ' ' {
+				j++
+			}
+			if j < len(ops) && j-end <= 2*context {
+				end = j
+				continue
+			}
+			end = min(j, end+context)
+			break
+		}
+
+		var aStart, bStart, aLen, bLen int
+		for _, op := range ops[:start] {
+			if op.kind != '+' {
+				aStart++
+			}
+			if op.kind != '-' {
+				bStart++
+			}
+		}
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				aLen++
+			}
+			if op.kind != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(buf, "@@ -%s +%s @@\n", hunkRange(aStart, aLen), hunkRange(bStart, bLen))
+		for _, op := range ops[start:end] {
+			buf.WriteByte(op.kind)
+			buf.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		i = end
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+func splitLines(b []byte) []string {
+	lines := strings.SplitAfter(string(b), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+type diffOp struct {
+	kind byte // ' ' for unchanged, '-' for deleted, '+' for inserted
+	line string
+}
+
+// diffLines computes a minimal edit script from a to b
+// using the algorithm in "An O(ND) Difference Algorithm and Its Variations"
+// by Eugene W. Myers.
+func diffLines(a, b []string) []diffOp {
+	var (
+		n, m  = len(a), len(b)
+		off   = n + m + 1
+		v     = make([]int, 2*off+1)
+		trace [][]int
+	)
+
+	// Forward pass:
+	// for each number of edits d,
+	// find the furthest-reaching path on each diagonal k.
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		done := false
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+		if done {
+			break
+		}
+	}
+
+	// Backward pass:
+	// walk the trace from the end to recover the edits.
+	var (
+		result []diffOp
+		x, y   = n, m
+	)
+	for d := len(trace) - 1; d >= 0; d-- {
+		var (
+			v     = trace[d]
+			k     = x - y
+			prevK int
+		)
+		if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[off+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			result = append(result, diffOp{kind: ' ', line: a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				result = append(result, diffOp{kind: '+', line: b[y-1]})
+			} else {
+				result = append(result, diffOp{kind: '-', line: a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
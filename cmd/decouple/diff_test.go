@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	cases := []struct {
+		a, b, want string
+	}{{
+		a:    "a\nb\nc\n",
+		b:    "a\nb\nc\n",
+		want: "--- x\n+++ x\n",
+	}, {
+		a:    "a\nb\nc\n",
+		b:    "a\nB\nc\n",
+		want: "--- x\n+++ x\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+	}, {
+		a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+		b:    "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+		want: "--- x\n+++ x\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -9,4 +10,3 @@\n 9\n 10\n 11\n-12\n",
+	}, {
+		a:    "a\nb",
+		b:    "a\nc\n",
+		want: "--- x\n+++ x\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n",
+	}}
+
+	for _, tc := range cases {
+		buf := new(bytes.Buffer)
+		if err := unifiedDiff(buf, "x", []byte(tc.a), []byte(tc.b)); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tc.want {
+			t.Errorf("diff of %q and %q: got\n%s\nwant\n%s", tc.a, tc.b, buf.String(), tc.want)
+		}
+	}
+}
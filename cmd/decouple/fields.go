@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/bobg/go-generics/v3/maps"
+
+	"github.com/bobg/decouple"
+)
+
+// namer is satisfied by decouple.Checker.
+type namer interface {
+	NameForMethods(decouple.MethodMap) string
+}
+
+// showFields reports the results of decouple.Checker.CheckFields
+// in the format selected by opts.
+func showFields(w io.Writer, n namer, fields []decouple.FieldTuple, opts options) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	for _, ft := range fields {
+		p := ft.Pos()
+		jt := jtuple{
+			PackageName: ft.P.Name,
+			FileName:    p.Filename,
+			Line:        p.Line,
+			Column:      p.Column,
+			TypeName:    ft.T.Name.Name,
+		}
+		showMembers(w, n, &jt, "type "+ft.T.Name.Name, ft.M, ft.Fields, opts)
+		if opts.doJSON && len(jt.Params) > 0 {
+			if err := enc.Encode(jt); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// showMembers reports on the struct fields, package-level variables,
+// or interface method parameters in results,
+// with the eligible ones also in mm.
+// With opts.doJSON it adds them to jt.Params,
+// otherwise it writes them to w
+// (after a line with jt's position and header,
+// if there is anything to report).
+// It tells whether it reported anything.
+func showMembers(w io.Writer, n namer, jt *jtuple, header string, mm map[string]decouple.MethodMap, results map[string]decouple.ParamResult, opts options) bool {
+	names := maps.Keys(mm)
+	if opts.why {
+		names = maps.Keys(results)
+	}
+	sort.Strings(names)
+
+	var showedHeader bool
+	for _, name := range names {
+		res := results[name]
+		if len(mm[name]) == 0 && res.Reason == nil {
+			continue
+		}
+
+		methods := maps.Keys(mm[name])
+		sort.Strings(methods)
+		intfName := n.NameForMethods(mm[name])
+
+		if opts.doJSON {
+			jp := jparam{Name: name}
+			if res.Reason != nil {
+				jp.Reason = res.Reason.String()
+			} else {
+				jp.Methods = methods
+				jp.InterfaceName = intfName
+				for method, uses := range res.Uses {
+					if jp.Uses == nil {
+						jp.Uses = make(map[string][]juse)
+					}
+					for _, use := range uses {
+						jp.Uses[method] = append(jp.Uses[method], juse{
+							Via:      use.Via,
+							FileName: use.Pos.Filename,
+							Line:     use.Pos.Line,
+							Column:   use.Pos.Column,
+						})
+					}
+				}
+			}
+			jt.Params = append(jt.Params, jp)
+			continue
+		}
+
+		if !showedHeader {
+			fmt.Fprintf(w, "%s:%d:%d: %s\n", jt.FileName, jt.Line, jt.Column, header)
+			showedHeader = true
+		}
+		if res.Reason != nil {
+			fmt.Fprintf(w, "    %s: not decoupled: %s\n", name, res.Reason)
+			continue
+		}
+		desc := fmt.Sprint(methods)
+		if intfName != "" {
+			desc = intfName
+		}
+		fmt.Fprintf(w, "    %s: %s\n", name, desc)
+		if opts.uses {
+			for _, method := range methods {
+				for _, use := range res.Uses[method] {
+					fmt.Fprintf(w, "        %s %s\n", method, use)
+				}
+			}
+		}
+	}
+	return showedHeader || len(jt.Params) > 0
+}
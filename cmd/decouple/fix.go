@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bobg/errors"
+
+	"github.com/bobg/decouple"
+)
+
+type fixer interface {
+	Fix(...decouple.Tuple) (decouple.Fix, error)
+}
+
+// fix rewrites the files containing the given tuples.
+// If showDiff is true,
+// the files are left alone
+// and a diff of the changes is written to w instead.
+func fix(w io.Writer, checker fixer, tuples []decouple.Tuple, showDiff bool) error {
+	var (
+		filenames []string
+		byFile    = make(map[string][]decouple.Tuple)
+	)
+	for _, tuple := range tuples {
+		if !hasFindings(tuple) {
+			continue
+		}
+		if _, err := checker.Fix(tuple); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: skipping %s: %s\n", tuple.Pos(), funcName(tuple), err)
+			continue
+		}
+		filename := tuple.Pos().Filename
+		if _, ok := byFile[filename]; !ok {
+			filenames = append(filenames, filename)
+		}
+		byFile[filename] = append(byFile[filename], tuple)
+	}
+
+	for _, filename := range filenames {
+		f, err := checker.Fix(byFile[filename]...)
+		if err != nil {
+			return errors.Wrapf(err, "computing changes to %s", filename)
+		}
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return errors.Wrapf(err, "reading %s", filename)
+		}
+		out, err := f.Apply(src)
+		if err != nil {
+			return errors.Wrapf(err, "changing %s", filename)
+		}
+		if bytes.Equal(src, out) {
+			continue
+		}
+		if showDiff {
+			if err := unifiedDiff(w, filename, src, out); err != nil {
+				return errors.Wrapf(err, "writing diff for %s", filename)
+			}
+			continue
+		}
+		info, err := os.Stat(filename)
+		if err != nil {
+			return errors.Wrapf(err, "getting info for %s", filename)
+		}
+		if err := os.WriteFile(filename, out, info.Mode().Perm()); err != nil {
+			return errors.Wrapf(err, "writing %s", filename)
+		}
+	}
+
+	return nil
+}
+
+func hasFindings(tuple decouple.Tuple) bool {
+	for _, mm := range tuple.M {
+		if len(mm) > 0 {
+			return true
+		}
+	}
+	return len(tuple.T) > 0 || len(tuple.I) > 0 || len(tuple.S) > 0
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"io"
+
+	"github.com/bobg/decouple"
+)
+
+// showImpls reports the results of decouple.Checker.CheckInterfaceMethods
+// in the format selected by opts.
+func showImpls(w io.Writer, n namer, mts []decouple.MethodTuple, opts options) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	for _, mt := range mts {
+		var (
+			method  = mt.F.Names[0].Name
+			mm      = make(map[string]decouple.MethodMap)
+			results = make(map[string]decouple.ParamResult)
+		)
+		for i, res := range mt.Params {
+			name := mt.ParamName(i)
+			results[name] = res
+			if len(res.Methods) > 0 {
+				mm[name] = res.Methods
+			}
+		}
+
+		p := mt.Pos()
+		jt := jtuple{
+			PackageName: mt.P.Name,
+			FileName:    p.Filename,
+			Line:        p.Line,
+			Column:      p.Column,
+			FuncName:    method,
+			TypeName:    mt.T.Name.Name,
+		}
+		if !showMembers(w, n, &jt, mt.T.Name.Name+"."+method, mm, results, opts) {
+			continue
+		}
+
+		q := types.RelativeTo(mt.P.Types)
+		if opts.doJSON {
+			for _, impl := range mt.Impls {
+				ip := impl.Pos()
+				jt.Impls = append(jt.Impls, jimpl{
+					Name:     implName(impl, q),
+					FileName: ip.Filename,
+					Line:     ip.Line,
+					Column:   ip.Column,
+				})
+			}
+			if err := enc.Encode(jt); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, impl := range mt.Impls {
+			fmt.Fprintf(w, "    implemented by %s at %s\n", implName(impl, q), impl.Pos())
+		}
+	}
+
+	return nil
+}
+
+// implName produces the name of the method in impl,
+// e.g. "(*pgStore).Put".
+func implName(impl decouple.Tuple, q types.Qualifier) string {
+	fn, ok := impl.P.TypesInfo.Defs[impl.F.Name].(*types.Func)
+	if !ok {
+		return impl.F.Name.Name
+	}
+	recv := types.TypeString(fn.Type().(*types.Signature).Recv().Type(), q)
+	if _, ok := fn.Type().(*types.Signature).Recv().Type().(*types.Pointer); ok {
+		recv = "(" + recv + ")"
+	}
+	return recv + "." + fn.Name()
+}
+
+type jimpl struct {
+	Name         string
+	FileName     string
+	Line, Column int
+}
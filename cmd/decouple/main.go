@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"go/types"
 	"io"
 	"os"
 	"sort"
+	"strings"
 
 	"github.com/bobg/errors"
 	"github.com/bobg/go-generics/v3/maps"
@@ -15,21 +17,44 @@ import (
 )
 
 func main() {
-	var (
-		verbose bool
-		doJSON  bool
-	)
-	flag.BoolVar(&verbose, "v", false, "verbose")
-	flag.BoolVar(&doJSON, "json", false, "output in JSON format")
+	var opts options
+	flag.BoolVar(&opts.verbose, "v", false, "verbose")
+	flag.BoolVar(&opts.doJSON, "json", false, "output in JSON format")
+	flag.BoolVar(&opts.fix, "fix", false, "rewrite files in place to decouple parameters")
+	flag.BoolVar(&opts.diff, "diff", false, "like -fix but print a diff instead of rewriting files")
+	flag.BoolVar(&opts.verify, "verify", false, "check that each suggestion compiles, dropping those that don't")
+	flag.BoolVar(&opts.why, "why", false, "also report why other parameters are not eligible for decoupling")
+	flag.BoolVar(&opts.uses, "uses", false, "show where each method is required")
+	flag.BoolVar(&opts.ssa, "ssa", false, "analyze the SSA form of functions instead of their syntax")
+	flag.StringVar(&opts.style, "style", "interface", "form of suggestions: interface, generic, or both (reports only)")
+	flag.BoolVar(&opts.typesets, "typesets", false, "also suggest type-set constraints for parameters of basic types, from the operators used on them")
+	flag.BoolVar(&opts.fields, "fields", false, "also report struct fields that could have interface types (reports only)")
+	flag.BoolVar(&opts.vars, "vars", false, "also report package-level variables that could have interface types (reports only)")
+	flag.BoolVar(&opts.funclits, "funclits", false, "also check the parameters of function literals that are called directly or through a local variable")
+	flag.BoolVar(&opts.impls, "impls", false, "also report interface method parameters that could be decoupled in every implementation together (reports only)")
+	flag.BoolVar(&opts.segregation, "segregation", false, "also report interface methods nothing calls, and consumers needing few of an interface's methods (reports only)")
+	flag.BoolVar(&opts.abstractions, "abstractions", false, "also report interface parameters of unexported or internal functions that are only ever given one concrete type and are not mocked in tests (reports only)")
 	flag.Parse()
 
-	if err := run(os.Stdout, verbose, doJSON, flag.Args()); err != nil {
+	if err := run(os.Stdout, opts, flag.Args()); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
-func run(w io.Writer, verbose, doJSON bool, args []string) error {
+type options struct {
+	verbose, doJSON    bool
+	fix, diff          bool
+	verify, why        bool
+	uses, ssa          bool
+	typesets, funclits bool
+	fields, vars       bool
+	impls, segregation bool
+	abstractions       bool
+	style              string
+}
+
+func run(w io.Writer, opts options, args []string) error {
 	var dir string
 	switch len(args) {
 	case 0:
@@ -37,14 +62,36 @@ func run(w io.Writer, verbose, doJSON bool, args []string) error {
 	case 1:
 		dir = args[0]
 	default:
-		return fmt.Errorf("Usage: %s [-v] [-json] [DIR]", os.Args[0])
+		return fmt.Errorf("Usage: %s [-v] [-ssa] [-json] [-why] [-uses] [-style=interface|generic|both] [-typesets] [-funclits] [-fields] [-vars] [-impls] [-segregation] [-abstractions] [-fix | -diff] [-verify] [DIR]", os.Args[0])
+	}
+
+	var style decouple.Style
+	switch opts.style {
+	case "", "interface":
+	case "generic":
+		style = decouple.StyleGeneric
+	case "both":
+		if opts.fix || opts.diff {
+			return fmt.Errorf("-style=both cannot be used with -fix or -diff")
+		}
+	default:
+		return fmt.Errorf("unknown style %q (want interface, generic, or both)", opts.style)
+	}
+	if (opts.fields || opts.vars || opts.impls || opts.segregation || opts.abstractions) && (opts.fix || opts.diff) {
+		return fmt.Errorf("-fields, -vars, -impls, -segregation, and -abstractions cannot be used with -fix or -diff")
 	}
 
 	checker, err := decouple.NewCheckerFromDir(dir)
 	if err != nil {
 		return errors.Wrapf(err, "creating checker for %s", dir)
 	}
-	checker.Verbose = verbose
+	checker.Verbose = opts.verbose
+	if opts.ssa {
+		checker.Engine = decouple.EngineSSA
+	}
+	checker.Style = style
+	checker.TypeSets = opts.typesets
+	checker.FuncLits = opts.funclits
 
 	tuples, err := checker.Check()
 	if err != nil {
@@ -52,52 +99,253 @@ func run(w io.Writer, verbose, doJSON bool, args []string) error {
 	}
 
 	sort.Slice(tuples, func(i, j int) bool {
-		iPos, jPos := tuples[i].Pos(), tuples[j].Pos()
-		if iPos.Filename < jPos.Filename {
-			return true
+		return decouple.PositionLess(tuples[i].Pos(), tuples[j].Pos())
+	})
+
+	if opts.verify {
+		tuples, err = verify(checker, tuples)
+		if err != nil {
+			return errors.Wrap(err, "verifying suggestions")
 		}
-		if iPos.Filename > jPos.Filename {
-			return false
+	}
+
+	if opts.fix || opts.diff {
+		err := fix(w, checker, tuples, opts.diff)
+		return errors.Wrap(err, "fixing files")
+	}
+
+	var fields []decouple.FieldTuple
+	if opts.fields {
+		if fields, err = checker.CheckFields(); err != nil {
+			return errors.Wrapf(err, "checking fields in %s", dir)
 		}
-		return iPos.Offset < jPos.Offset
-	})
+		sort.Slice(fields, func(i, j int) bool {
+			return decouple.PositionLess(fields[i].Pos(), fields[j].Pos())
+		})
+	}
+	var vars []decouple.VarTuple
+	if opts.vars {
+		if vars, err = checker.CheckVars(); err != nil {
+			return errors.Wrapf(err, "checking variables in %s", dir)
+		}
+		sort.Slice(vars, func(i, j int) bool {
+			return decouple.PositionLess(vars[i].Pos(), vars[j].Pos())
+		})
+	}
+	var impls []decouple.MethodTuple
+	if opts.impls {
+		if impls, err = checker.CheckInterfaceMethods(); err != nil {
+			return errors.Wrapf(err, "checking interface methods in %s", dir)
+		}
+		sort.Slice(impls, func(i, j int) bool {
+			return decouple.PositionLess(impls[i].Pos(), impls[j].Pos())
+		})
+	}
+	var usages []decouple.InterfaceUsage
+	if opts.segregation {
+		if usages, err = checker.CheckInterfaceUsage(); err != nil {
+			return errors.Wrapf(err, "checking interface usage in %s", dir)
+		}
+		sort.Slice(usages, func(i, j int) bool {
+			return decouple.PositionLess(usages[i].Pos(), usages[j].Pos())
+		})
+	}
+	var abstractions []decouple.Abstraction
+	if opts.abstractions {
+		// This needs to see the tests too.
+		tchecker, err := decouple.NewCheckerFromDirWithTests(dir)
+		if err != nil {
+			return errors.Wrapf(err, "creating checker with tests for %s", dir)
+		}
+		if abstractions, err = tchecker.CheckAbstractions(); err != nil {
+			return errors.Wrapf(err, "checking abstractions in %s", dir)
+		}
+	}
 
-	if doJSON {
-		err := showJSON(w, checker, tuples)
-		return errors.Wrap(err, "formatting JSON output")
+	if opts.doJSON {
+		if err := showJSON(w, checker, tuples, opts.why, opts.style == "generic" || opts.style == "both"); err != nil {
+			return errors.Wrap(err, "formatting JSON output")
+		}
+		if err := showFields(w, checker, fields, opts); err != nil {
+			return errors.Wrap(err, "formatting JSON output for fields")
+		}
+		if err := showVars(w, checker, vars, opts); err != nil {
+			return errors.Wrap(err, "formatting JSON output for variables")
+		}
+		if err := showImpls(w, checker, impls, opts); err != nil {
+			return errors.Wrap(err, "formatting JSON output for interface methods")
+		}
+		if err := showUsage(w, checker, usages, opts); err != nil {
+			return errors.Wrap(err, "formatting JSON output for interface usage")
+		}
+		err := showAbstractions(w, abstractions, opts)
+		return errors.Wrap(err, "formatting JSON output for abstractions")
 	}
 
 	for _, tuple := range tuples {
-		var showedFuncName bool
+		var (
+			showedFuncName bool
+			tpNames        map[string]string
+		)
+		if opts.style == "generic" || opts.style == "both" {
+			tpNames = checker.TypeParamNames(tuple)
+		}
 
 		params := maps.Keys(tuple.M)
+		if opts.why {
+			params = maps.Keys(tuple.Params)
+		}
 		sort.Strings(params)
 		for _, param := range params {
-			mm := tuple.M[param]
+			var (
+				mm     = tuple.M[param]
+				reason *decouple.Reason
+			)
 			if len(mm) == 0 {
-				continue
+				if !opts.why {
+					continue
+				}
+				if reason = tuple.Params[param].Reason; reason == nil {
+					continue
+				}
 			}
 
 			if !showedFuncName {
-				fmt.Fprintf(w, "%s: %s\n", tuple.Pos(), tuple.F.Name.Name)
+				fmt.Fprintf(w, "%s: %s\n", tuple.Pos(), funcName(tuple))
 				showedFuncName = true
 			}
 
-			if intfName := checker.NameForMethods(mm); intfName != "" {
-				fmt.Fprintf(w, "    %s: %s\n", param, intfName)
+			if reason != nil {
+				fmt.Fprintf(w, "    %s: not decoupled: %s\n", param, reason)
 				continue
 			}
 
-			methods := maps.Keys(tuple.M[param])
+			methods := maps.Keys(mm)
+			sort.Strings(methods)
+
+			desc := fmt.Sprint(methods)
+			if intfName := checker.NameForMethods(mm); intfName != "" {
+				desc = intfName
+			}
+			var (
+				res      = tuple.Params[param]
+				tp, isTP = tpNames[param]
+				text     = desc
+			)
+			if res.Elems {
+				if text, err = tuple.WithElem(param, desc); err != nil {
+					return errors.Wrapf(err, "describing %s", param)
+				}
+			}
+			if isTP {
+				gtext := fmt.Sprintf("[%s %s]", tp, desc)
+				if res.Elems {
+					etext, err := tuple.WithElem(param, tp)
+					if err != nil {
+						return errors.Wrapf(err, "describing %s", param)
+					}
+					gtext += " " + etext
+				}
+				if opts.style == "both" {
+					text = fmt.Sprintf("%s or %s", text, gtext)
+				} else {
+					text = gtext
+				}
+			}
+			fmt.Fprintf(w, "    %s: %s\n", param, text)
+
+			if res.Elems && (!isTP || opts.style == "both") {
+				if strings.HasPrefix(text, "...") {
+					fmt.Fprintf(w, "        callers passing a slice with ... must convert it element by element\n")
+				} else {
+					fmt.Fprintf(w, "        callers must convert their arguments element by element\n")
+				}
+			}
+
+			for _, u := range tuple.Params[param].Upgrades {
+				fmt.Fprintf(w, "        %s\n", u)
+			}
+
+			if opts.uses {
+				uses := tuple.Params[param].Uses
+				for _, method := range methods {
+					for _, use := range uses[method] {
+						fmt.Fprintf(w, "        %s %s\n", method, use)
+					}
+				}
+			}
+		}
+
+		iters := maps.Keys(tuple.I)
+		sort.Strings(iters)
+		for _, param := range iters {
+			if !showedFuncName {
+				fmt.Fprintf(w, "%s: %s\n", tuple.Pos(), funcName(tuple))
+				showedFuncName = true
+			}
+			it := tuple.I[param]
+			fmt.Fprintf(w, "    %s: %s\n", param, it.TypeString(types.RelativeTo(tuple.P.Types)))
+			fmt.Fprintf(w, "        callers can convert their arguments with %s\n", it.Adapter)
+		}
+
+		tsNames := checker.TypeSetNames(tuple)
+		for i, ts := range tuple.S {
+			if !showedFuncName {
+				fmt.Fprintf(w, "%s: %s\n", tuple.Pos(), funcName(tuple))
+				showedFuncName = true
+			}
+			fmt.Fprintf(w, "    %s: [%s %s]\n", strings.Join(ts.Params, ", "), tsNames[i], ts.Constraint(types.RelativeTo(tuple.P.Types)))
+			switch {
+			case len(ts.Results) == 0:
+			case tuple.F.Type.Results.NumFields() == 1:
+				fmt.Fprintf(w, "        the result is also %s\n", tsNames[i])
+			default:
+				for _, r := range ts.Results {
+					fmt.Fprintf(w, "        result %d is also %s\n", r+1, tsNames[i])
+				}
+			}
+		}
+
+		tparams := maps.Keys(tuple.T)
+		sort.Strings(tparams)
+		for _, tparam := range tparams {
+			if !showedFuncName {
+				fmt.Fprintf(w, "%s: %s\n", tuple.Pos(), funcName(tuple))
+				showedFuncName = true
+			}
+
+			mm := tuple.T[tparam]
+			methods := maps.Keys(mm)
 			sort.Strings(methods)
-			fmt.Fprintf(w, "    %s: %v\n", param, methods)
+
+			switch intfName := checker.NameForMethods(mm); {
+			case len(mm) == 0:
+				fmt.Fprintf(w, "    type %s: any\n", tparam)
+			case intfName != "":
+				fmt.Fprintf(w, "    type %s: %s\n", tparam, intfName)
+			default:
+				fmt.Fprintf(w, "    type %s: %v\n", tparam, methods)
+			}
 		}
 	}
 
-	return nil
+	if err := showFields(w, checker, fields, opts); err != nil {
+		return errors.Wrap(err, "reporting fields")
+	}
+	if err := showVars(w, checker, vars, opts); err != nil {
+		return errors.Wrap(err, "reporting variables")
+	}
+	if err := showImpls(w, checker, impls, opts); err != nil {
+		return errors.Wrap(err, "reporting interface methods")
+	}
+	if err := showUsage(w, checker, usages, opts); err != nil {
+		return errors.Wrap(err, "reporting interface usage")
+	}
+	err = showAbstractions(w, abstractions, opts)
+	return errors.Wrap(err, "reporting abstractions")
 }
 
-func showJSON(w io.Writer, checker decouple.Checker, tuples []decouple.Tuple) error {
+func showJSON(w io.Writer, checker decouple.Checker, tuples []decouple.Tuple, why, generic bool) error {
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
 
@@ -109,6 +357,11 @@ func showJSON(w io.Writer, checker decouple.Checker, tuples []decouple.Tuple) er
 			Line:        p.Line,
 			Column:      p.Column,
 			FuncName:    tuple.F.Name.Name,
+			Literal:     tuple.L != nil,
+		}
+		var tpNames map[string]string
+		if generic {
+			tpNames = checker.TypeParamNames(tuple)
 		}
 		for param, mm := range tuple.M {
 			if len(mm) == 0 {
@@ -122,9 +375,75 @@ func showJSON(w io.Writer, checker decouple.Checker, tuples []decouple.Tuple) er
 			if intfName := checker.NameForMethods(mm); intfName != "" {
 				jp.InterfaceName = intfName
 			}
+			jp.TypeParamName = tpNames[param]
+			jp.Elems = tuple.Params[param].Elems
+			for method, uses := range tuple.Params[param].Uses {
+				if jp.Uses == nil {
+					jp.Uses = make(map[string][]juse)
+				}
+				for _, use := range uses {
+					jp.Uses[method] = append(jp.Uses[method], juse{
+						Via:      use.Via,
+						FileName: use.Pos.Filename,
+						Line:     use.Pos.Line,
+						Column:   use.Pos.Column,
+					})
+				}
+			}
+			for _, u := range tuple.Params[param].Upgrades {
+				jp.Upgrades = append(jp.Upgrades, jupgrade{
+					Interface: u.Interface,
+					FileName:  u.Pos.Filename,
+					Line:      u.Pos.Line,
+					Column:    u.Pos.Column,
+				})
+			}
 			jt.Params = append(jt.Params, jp)
 		}
-		if len(jt.Params) == 0 {
+		for param, it := range tuple.I {
+			jt.Params = append(jt.Params, jparam{
+				Name:    param,
+				Iter:    it.TypeString(types.RelativeTo(tuple.P.Types)),
+				Adapter: it.Adapter,
+			})
+		}
+		for tparam, mm := range tuple.T {
+			jp := jparam{
+				Name:    tparam,
+				Methods: maps.Keys(mm),
+			}
+			sort.Strings(jp.Methods)
+			if len(mm) == 0 {
+				jp.InterfaceName = "any"
+			} else if intfName := checker.NameForMethods(mm); intfName != "" {
+				jp.InterfaceName = intfName
+			}
+			jt.TypeParams = append(jt.TypeParams, jp)
+		}
+		tsNames := checker.TypeSetNames(tuple)
+		for i, ts := range tuple.S {
+			jt.TypeSets = append(jt.TypeSets, jtypeset{
+				Params:        ts.Params,
+				Results:       ts.Results,
+				TypeParamName: tsNames[i],
+				Constraint:    ts.Constraint(types.RelativeTo(tuple.P.Types)),
+			})
+		}
+		sort.Slice(jt.TypeParams, func(i, j int) bool {
+			return jt.TypeParams[i].Name < jt.TypeParams[j].Name
+		})
+		if why {
+			for param, res := range tuple.Params {
+				if len(tuple.M[param]) > 0 || res.Reason == nil {
+					continue
+				}
+				jt.Params = append(jt.Params, jparam{
+					Name:   param,
+					Reason: res.Reason.String(),
+				})
+			}
+		}
+		if len(jt.Params) == 0 && len(jt.TypeParams) == 0 && len(jt.TypeSets) == 0 {
 			continue
 		}
 		sort.Slice(jt.Params, func(i, j int) bool {
@@ -142,12 +461,91 @@ type jtuple struct {
 	PackageName  string
 	FileName     string
 	Line, Column int
-	FuncName     string
-	Params       []jparam
+	FuncName     string `json:",omitempty"`
+
+	// Literal tells whether the parameters are those of a function literal in FuncName,
+	// at Line and Column, with -funclits.
+	Literal bool `json:",omitempty"`
+
+	// TypeName is the name of the struct type whose fields are reported, with -fields,
+	// or, with -impls, of the interface type whose method FuncName has its parameters reported.
+	TypeName string `json:",omitempty"`
+
+	// VarNames are the names of the package-level variables
+	// declared together whose results are reported, with -vars.
+	VarNames []string `json:",omitempty"`
+
+	// Impls are the implementations of the interface method, with -impls.
+	// Their parameters change along with the method's.
+	Impls []jimpl `json:",omitempty"`
+
+	Params []jparam
+
+	// TypeParams are the type parameters whose constraints could be narrowed.
+	TypeParams []jparam `json:",omitempty"`
+
+	// TypeSets are the groups of parameters of basic types
+	// that could have a new type parameter constrained by a type set.
+	// They are reported only with -typesets.
+	TypeSets []jtypeset `json:",omitempty"`
+}
+
+// funcName describes the function that tuple is about.
+func funcName(tuple decouple.Tuple) string {
+	if tuple.L != nil {
+		return "function literal in " + tuple.F.Name.Name
+	}
+	return tuple.F.Name.Name
+}
+
+type jtypeset struct {
+	Params []string
+
+	// Results are the indexes (from 0) of the results that would have the type parameter too.
+	Results []int `json:",omitempty"`
+
+	TypeParamName string
+	Constraint    string
 }
 
 type jparam struct {
 	Name          string
 	Methods       []string `json:",omitempty"`
 	InterfaceName string   `json:",omitempty"`
+	Reason        string   `json:",omitempty"`
+
+	// TypeParamName is the name of the type parameter
+	// that the parameter could have instead,
+	// constrained by InterfaceName or an interface with Methods.
+	// It is set only with -style=generic or -style=both.
+	TypeParamName string `json:",omitempty"`
+
+	// Elems tells whether the methods are needed by the parameter's elements,
+	// as with []io.Reader instead of []*os.File.
+	Elems bool `json:",omitempty"`
+
+	// Iter is the iterator type that the parameter could have instead,
+	// e.g. iter.Seq[string],
+	// and Adapter the function callers can use to produce it,
+	// e.g. slices.Values.
+	Iter    string `json:",omitempty"`
+	Adapter string `json:",omitempty"`
+
+	// Uses maps each method to the places that require it.
+	Uses map[string][]juse `json:",omitempty"`
+
+	// Upgrades are the optional interfaces that narrowing the parameter loses.
+	Upgrades []jupgrade `json:",omitempty"`
+}
+
+type juse struct {
+	Via          string `json:",omitempty"`
+	FileName     string
+	Line, Column int
+}
+
+type jupgrade struct {
+	Interface    string
+	FileName     string
+	Line, Column int
 }
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/bobg/go-generics/v3/maps"
+
+	"github.com/bobg/decouple"
+)
+
+// showUsage reports the results of decouple.Checker.CheckInterfaceUsage
+// in the format selected by opts:
+// the methods of each interface that nothing needs,
+// and the consumers needing at most half of its methods
+// (or, with opts.why, all its consumers).
+func showUsage(w io.Writer, n namer, usages []decouple.InterfaceUsage, opts options) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	for _, u := range usages {
+		var consumers []decouple.Consumer
+		for _, c := range u.Consumers {
+			if opts.why || (len(u.Methods) > 1 && 2*len(c.Methods) <= len(u.Methods)) {
+				consumers = append(consumers, c)
+			}
+		}
+		if len(u.Unused) == 0 && len(consumers) == 0 {
+			continue
+		}
+
+		p := u.Pos()
+
+		if opts.doJSON {
+			ju := jusage{
+				PackageName: u.P.Name,
+				FileName:    p.Filename,
+				Line:        p.Line,
+				Column:      p.Column,
+				TypeName:    u.T.Name.Name,
+				Methods:     sortedKeys(u.Methods),
+				Unused:      u.Unused,
+			}
+			for _, c := range consumers {
+				jc := jconsumer{
+					Kind:     consumerKind(c.Kind),
+					Name:     c.Name,
+					Context:  c.Context,
+					FileName: c.Pos.Filename,
+					Line:     c.Pos.Line,
+					Column:   c.Pos.Column,
+					Methods:  sortedKeys(c.Methods),
+				}
+				if len(c.Methods) > 0 {
+					jc.InterfaceName = n.NameForMethods(c.Methods)
+				}
+				ju.Consumers = append(ju.Consumers, jc)
+			}
+			if err := enc.Encode(ju); err != nil {
+				return err
+			}
+			continue
+		}
+
+		plural := "s"
+		if len(u.Methods) == 1 {
+			plural = ""
+		}
+		fmt.Fprintf(w, "%s:%d:%d: interface %s (%d method%s)\n", p.Filename, p.Line, p.Column, u.T.Name.Name, len(u.Methods), plural)
+		if len(u.Unused) > 0 {
+			fmt.Fprintf(w, "    never called: %v\n", u.Unused)
+		}
+		for _, c := range consumers {
+			desc := fmt.Sprint(sortedKeys(c.Methods))
+			if len(c.Methods) == 0 {
+				desc = "no methods"
+			} else if intfName := n.NameForMethods(c.Methods); intfName != "" {
+				desc = intfName
+			}
+			fmt.Fprintf(w, "    %s %s: %s (at %s)\n", consumerKind(c.Kind), consumerName(c), desc, c.Pos)
+		}
+	}
+
+	return nil
+}
+
+func sortedKeys(mm decouple.MethodMap) []string {
+	result := maps.Keys(mm)
+	sort.Strings(result)
+	return result
+}
+
+func consumerKind(kind decouple.ConsumerKind) string {
+	switch kind {
+	case decouple.ConsumerParam:
+		return "param"
+	case decouple.ConsumerField:
+		return "field"
+	default:
+		return "var"
+	}
+}
+
+// consumerName qualifies the name of c with its context,
+// e.g. "Sync.db" for parameter db of function Sync.
+func consumerName(c decouple.Consumer) string {
+	if c.Context == "" {
+		return c.Name
+	}
+	return c.Context + "." + c.Name
+}
+
+type jusage struct {
+	PackageName  string
+	FileName     string
+	Line, Column int
+	TypeName     string
+
+	// Methods is the method set of the interface.
+	Methods []string
+
+	// Unused are the methods nothing needs.
+	Unused []string `json:",omitempty"`
+
+	// Consumers are those needing at most half of Methods,
+	// or, with -why, all of them.
+	Consumers []jconsumer `json:",omitempty"`
+}
+
+type jconsumer struct {
+	Kind          string // "param", "field", or "var"
+	Name          string
+	Context       string `json:",omitempty"`
+	FileName      string
+	Line, Column  int
+	Methods       []string
+	InterfaceName string `json:",omitempty"`
+}
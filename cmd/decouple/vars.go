@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/bobg/decouple"
+)
+
+// showVars reports the results of decouple.Checker.CheckVars
+// in the format selected by opts.
+func showVars(w io.Writer, n namer, vars []decouple.VarTuple, opts options) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	for _, vt := range vars {
+		var names []string
+		for _, name := range vt.V.Names {
+			names = append(names, name.Name)
+		}
+
+		p := vt.Pos()
+		jt := jtuple{
+			PackageName: vt.P.Name,
+			FileName:    p.Filename,
+			Line:        p.Line,
+			Column:      p.Column,
+			VarNames:    names,
+		}
+		showMembers(w, n, &jt, "var "+strings.Join(names, ", "), vt.M, vt.Vars, opts)
+		if opts.doJSON && len(jt.Params) > 0 {
+			if err := enc.Encode(jt); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/bobg/errors"
+	"github.com/bobg/go-generics/v3/maps"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/bobg/decouple"
+)
+
+type verifier interface {
+	Verify(decouple.Tuple) ([]packages.Error, error)
+}
+
+// verify removes from tuples the suggestions that fail decouple.Checker.Verify,
+// reporting each one on stderr.
+func verify(checker verifier, tuples []decouple.Tuple) ([]decouple.Tuple, error) {
+	for i, tuple := range tuples {
+		if !hasFindings(tuple) {
+			continue
+		}
+		errs, err := checker.Verify(tuple)
+		if err != nil {
+			return nil, errors.Wrapf(err, "verifying %s", funcName(tuple))
+		}
+		if len(errs) == 0 {
+			continue
+		}
+
+		// Something is wrong.
+		// Try the parameters one at a time to see which suggestions are OK,
+		// then the type parameters all together,
+		// then the iterators all together,
+		// and then the type sets all together.
+		params := maps.Keys(tuple.M)
+		sort.Strings(params)
+		tuple.T = nil
+		tuple.I = nil
+		tuple.S = nil
+
+		m := make(map[string]decouple.MethodMap)
+		for _, param := range params {
+			mm := tuple.M[param]
+			if len(mm) == 0 {
+				continue
+			}
+			if len(params) > 1 || len(tuples[i].T) > 0 || len(tuples[i].I) > 0 || len(tuples[i].S) > 0 {
+				sub := tuple
+				sub.M = map[string]decouple.MethodMap{param: mm}
+				errs, err = checker.Verify(sub)
+				if err != nil {
+					return nil, errors.Wrapf(err, "verifying parameter %s of %s", param, funcName(tuple))
+				}
+			}
+			if len(errs) == 0 {
+				m[param] = mm
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "%s: %s: dropping suggestion for %s, which does not compile: %s\n", tuple.Pos(), funcName(tuple), param, errs[0])
+			tuples[i].Params[param] = decouple.ParamResult{
+				Reason: &decouple.Reason{Kind: decouple.ReasonDoesNotCompile, Detail: errs[0].Msg},
+			}
+		}
+		tuples[i].M = m
+
+		if len(tuples[i].T) > 0 {
+			sub := tuples[i]
+			sub.M = nil
+			sub.I = nil
+			sub.S = nil
+			errs, err = checker.Verify(sub)
+			if err != nil {
+				return nil, errors.Wrapf(err, "verifying type parameters of %s", funcName(tuple))
+			}
+			if len(errs) > 0 {
+				fmt.Fprintf(os.Stderr, "%s: %s: dropping suggestions for type parameters, which do not compile: %s\n", tuple.Pos(), funcName(tuple), errs[0])
+				tuples[i].T = nil
+			}
+		}
+
+		if len(tuples[i].I) > 0 {
+			sub := tuples[i]
+			sub.M = nil
+			sub.T = nil
+			sub.S = nil
+			errs, err = checker.Verify(sub)
+			if err != nil {
+				return nil, errors.Wrapf(err, "verifying iterators of %s", funcName(tuple))
+			}
+			if len(errs) > 0 {
+				fmt.Fprintf(os.Stderr, "%s: %s: dropping suggestions for iterators, which do not compile: %s\n", tuple.Pos(), funcName(tuple), errs[0])
+				tuples[i].I = nil
+			}
+		}
+
+		if len(tuples[i].S) > 0 {
+			sub := tuples[i]
+			sub.M = nil
+			sub.T = nil
+			sub.I = nil
+			errs, err = checker.Verify(sub)
+			if err != nil {
+				return nil, errors.Wrapf(err, "verifying type sets of %s", funcName(tuple))
+			}
+			if len(errs) > 0 {
+				fmt.Fprintf(os.Stderr, "%s: %s: dropping suggestions for type sets, which do not compile: %s\n", tuple.Pos(), funcName(tuple), errs[0])
+				tuples[i].S = nil
+			}
+		}
+	}
+
+	return tuples, nil
+}
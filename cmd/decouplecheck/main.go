@@ -0,0 +1,18 @@
+// Command decouplecheck runs the decouple analyzer as a standalone command.
+//
+// Usage:
+//
+//	decouplecheck [-fix] [packages]
+//
+// See the documentation for golang.org/x/tools/go/analysis/singlechecker for more options.
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/bobg/decouple/passes/decouple"
+)
+
+func main() {
+	singlechecker.Main(decouple.Analyzer)
+}
@@ -0,0 +1,16 @@
+// Command decouplevet runs the decouple analyzer under go vet.
+//
+// Usage:
+//
+//	go vet -vettool=$(which decouplevet) [packages]
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/unitchecker"
+
+	"github.com/bobg/decouple/passes/decouple"
+)
+
+func main() {
+	unitchecker.Main(decouple.Analyzer)
+}
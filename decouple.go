@@ -5,12 +5,15 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
+	"sort"
 	"strings"
 
 	"github.com/bobg/errors"
+	"github.com/bobg/go-generics/v3/maps"
 	"github.com/bobg/go-generics/v3/set"
 	"github.com/bobg/go-generics/v3/slices"
 	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
 )
 
 // PkgMode is the minimal set of bit flags needed for the Config.Mode field of golang.org/x/go/packages
@@ -23,18 +26,83 @@ const PkgMode = packages.NeedName | packages.NeedFiles | packages.NeedImports |
 // or a function or function parameter in one.
 //
 // Set Verbose to true to get (very) verbose debugging output.
+// Set Engine to choose how function bodies are analyzed,
+// and Style to choose the form of the changes made by Fix.
+// Set TypeSets to also look for parameters of basic types
+// that could have type-set constraints
+// (see CheckTypeSets),
+// and FuncLits to also check the parameters of function literals
+// (see CheckFuncLits).
 type Checker struct {
-	Verbose bool
+	Verbose  bool
+	Engine   Engine
+	Style    Style
+	TypeSets bool
+	FuncLits bool
 
 	pkgs            []*packages.Package
-	namedInterfaces map[string]MethodMap // maps a package-qualified interface-type name to its method set
+	namedInterfaces map[string]namedInterface // maps a package-qualified interface-type name to its type and method set
+
+	// summaries maps the parameter objects of functions already checked
+	// to their results,
+	// for those parameters that are eligible for decoupling.
+	// It is consulted when checking calls to those functions.
+	summaries map[types.Object]paramSummary
+
+	ssaPkgs map[*packages.Package]*ssa.Package // cache for EngineSSA
+
+	// ifaceUses are the places in the Checker's packages
+	// where a value of concrete type is used as an interface.
+	ifaceUses []ifaceUse
+
+	// funcValues maps functions that are referred to other than by calling them
+	// to the first such reference.
+	funcValues map[*types.Func]funcValueUse
+
+	// uninferable are the function parameters
+	// whose types could not be inferred at some call in the Checker's packages
+	// if they were type parameters
+	// (see findUninferable).
+	uninferable set.Of[types.Object]
+
+	// allPkgs maps the paths of the Checker's packages and all their dependencies
+	// to the packages,
+	// and funcDecls caches the function declarations in them
+	// (see funcDecl).
+	allPkgs   map[string]*packages.Package
+	funcDecls map[*packages.Package]map[*types.Func]*ast.FuncDecl
+}
+
+type paramSummary struct {
+	ref ParamRef
+	res ParamResult
+}
+
+type namedInterface struct {
+	obj *types.TypeName
+	mm  MethodMap
 }
 
 // NewCheckerFromDir creates a new Checker containing packages loaded
 // (using "golang.org/x/go/packages".Load)
 // from the given directory tree.
 func NewCheckerFromDir(dir string) (Checker, error) {
-	conf := &packages.Config{Dir: dir, Mode: PkgMode}
+	return newCheckerFromDir(dir, false)
+}
+
+// NewCheckerFromDirWithTests is like NewCheckerFromDir
+// but also loads the packages' test files,
+// as the variants of the packages that include them
+// and as any external test packages.
+// This is for CheckAbstractions,
+// which needs to see the test files;
+// other checks report the same findings once for each variant of a package.
+func NewCheckerFromDirWithTests(dir string) (Checker, error) {
+	return newCheckerFromDir(dir, true)
+}
+
+func newCheckerFromDir(dir string, tests bool) (Checker, error) {
+	conf := &packages.Config{Dir: dir, Mode: PkgMode, Tests: tests}
 	pkgs, err := packages.Load(conf, "./...")
 	if err != nil {
 		return Checker{}, errors.Wrapf(err, "loading packages from %s", dir)
@@ -55,16 +123,40 @@ func NewCheckerFromDir(dir string) (Checker, error) {
 // with at least the bits in PkgMode set in the Config.Mode field.
 func NewCheckerFromPackages(pkgs []*packages.Package) Checker {
 	var (
-		namedInterfaces = make(map[string]MethodMap)
+		namedInterfaces = make(map[string]namedInterface)
 		seen            = set.New[*packages.Package]()
+		allPkgs         = make(map[string]*packages.Package)
 	)
 	for _, pkg := range pkgs {
 		findNamedInterfaces(pkg, seen, namedInterfaces)
 	}
-	return Checker{pkgs: pkgs, namedInterfaces: namedInterfaces}
+	for _, pkg := range seen.Slice() {
+		allPkgs[pkg.PkgPath] = pkg
+	}
+	var (
+		ifaceUses   []ifaceUse
+		funcValues  = make(map[*types.Func]funcValueUse)
+		uninferable = set.New[types.Object]()
+	)
+	for _, pkg := range pkgs {
+		ifaceUses = append(ifaceUses, findIfaceUses(pkg)...)
+		findFuncValues(pkg, funcValues)
+		uninferable.Add(findUninferable(pkg)...)
+	}
+	return Checker{
+		pkgs:            pkgs,
+		namedInterfaces: namedInterfaces,
+		summaries:       make(map[types.Object]paramSummary),
+		ssaPkgs:         make(map[*packages.Package]*ssa.Package),
+		ifaceUses:       ifaceUses,
+		funcValues:      funcValues,
+		uninferable:     uninferable,
+		allPkgs:         allPkgs,
+		funcDecls:       make(map[*packages.Package]map[*types.Func]*ast.FuncDecl),
+	}
 }
 
-func findNamedInterfaces(pkg *packages.Package, seen set.Of[*packages.Package], namedInterfaces map[string]MethodMap) {
+func findNamedInterfaces(pkg *packages.Package, seen set.Of[*packages.Package], namedInterfaces map[string]namedInterface) {
 	if seen.Has(pkg) {
 		return
 	}
@@ -74,49 +166,35 @@ func findNamedInterfaces(pkg *packages.Package, seen set.Of[*packages.Package],
 		findNamedInterfaces(ipkg, seen, namedInterfaces)
 	}
 
-	if isInternal(pkg.PkgPath) {
+	if isInternal(pkg.PkgPath) || pkg.Types == nil {
 		return
 	}
 
-	for _, file := range pkg.Syntax {
-		for _, decl := range file.Decls {
-			gendecl, ok := decl.(*ast.GenDecl)
-			if !ok {
-				continue
-			}
-			if gendecl.Tok != token.TYPE {
-				continue
-			}
-			for _, spec := range gendecl.Specs {
-				typespec, ok := spec.(*ast.TypeSpec)
-				if !ok {
-					// Should be impossible.
-					continue
-				}
-				if !ast.IsExported(typespec.Name.Name) {
-					continue
-				}
-				obj := pkg.TypesInfo.Defs[typespec.Name]
-				if obj == nil {
-					// Should be impossible.
-					continue
-				}
-				intf := getType[*types.Interface](obj.Type())
-				if intf == nil {
-					continue
-				}
-				mm := make(MethodMap)
-				addMethodsToMap(intf, mm)
-				name := pkg.PkgPath
-				if strings.ContainsAny(name, "./") {
-					name = `"` + name + `"`
-				}
-				name += "." + typespec.Name.Name
-				namedInterfaces[name] = mm
-			}
+	// Consult the package scope rather than the syntax trees,
+	// so that this works even for packages loaded without syntax
+	// (as in an analysis pass, where only the package being analyzed has syntax).
+	scope := pkg.Types.Scope()
+	for _, objname := range scope.Names() {
+		if !ast.IsExported(objname) {
+			continue
+		}
+		obj, ok := scope.Lookup(objname).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		intf := getType[*types.Interface](obj.Type())
+		if intf == nil {
+			continue
 		}
+		mm := make(MethodMap)
+		addMethodsToMap(intf, mm)
+		name := pkg.PkgPath
+		if strings.ContainsAny(name, "./") {
+			name = `"` + name + `"`
+		}
+		name += "." + objname
+		namedInterfaces[name] = namedInterface{obj: obj, mm: mm}
 	}
-
 }
 
 // Check checks all the packages in the Checker.
@@ -124,10 +202,15 @@ func findNamedInterfaces(pkg *packages.Package, seen set.Of[*packages.Package],
 // looking for parameters with concrete types that could be interfaces instead.
 // The result is a list of Tuples,
 // one for each function checked that has parameters eligible for decoupling.
+//
+// Packages are checked in dependency order,
+// so that a parameter passed to a function in another package
+// can use the result for that function's parameter
+// (see CheckPackage).
 func (ch Checker) Check() ([]Tuple, error) {
 	var result []Tuple
 
-	for _, pkg := range ch.pkgs {
+	for _, pkg := range ch.depOrder() {
 		pkgResult, err := ch.CheckPackage(pkg)
 		if err != nil {
 			return nil, errors.Wrapf(err, "analyzing package %s", pkg.PkgPath)
@@ -138,12 +221,74 @@ func (ch Checker) Check() ([]Tuple, error) {
 	return result, nil
 }
 
+// depOrder returns the packages in the Checker
+// ordered so that each one comes after the ones it imports.
+func (ch Checker) depOrder() []*packages.Package {
+	var (
+		result  []*packages.Package
+		members = set.New(ch.pkgs...)
+		seen    = set.New[*packages.Package]()
+	)
+
+	var visit func(*packages.Package)
+	visit = func(pkg *packages.Package) {
+		if seen.Has(pkg) {
+			return
+		}
+		seen.Add(pkg)
+
+		paths := maps.Keys(pkg.Imports)
+		sort.Strings(paths)
+		for _, path := range paths {
+			visit(pkg.Imports[path])
+		}
+		if members.Has(pkg) {
+			result = append(result, pkg)
+		}
+	}
+
+	for _, pkg := range ch.pkgs {
+		visit(pkg)
+	}
+	return result
+}
+
+// Assume records that parameter i of fn needs only the methods in mm,
+// as determined by an earlier check of fn's package
+// (e.g. in an analysis pass, using facts exported by the pass for that package).
+// Calls to fn in packages checked afterwards
+// treat the parameter as eligible for decoupling.
+func (ch Checker) Assume(fn *types.Func, i int, mm MethodMap) {
+	param := fn.Type().(*types.Signature).Params().At(i)
+	ch.summaries[param] = paramSummary{
+		ref: ParamRef{Func: fn, Name: param.Name()},
+		res: ParamResult{Methods: mm},
+	}
+}
+
 // CheckPackage checks a single package.
 // It should be one of the packages contained in the Checker.
 // The result is a list of Tuples,
 // one for each function checked that has parameters eligible for decoupling.
+//
+// A parameter passed to another function
+// is eligible for decoupling if the callee's parameter is,
+// in which case it needs (at least) the methods the callee's parameter needs.
+// This works for callees in other packages
+// when those packages have already been checked
+// (or their results supplied with Assume).
+// Within the package, since a caller may be checked before its callee,
+// functions with such parameters are rechecked
+// until no more parameters become eligible.
 func (ch Checker) CheckPackage(pkg *packages.Package) ([]Tuple, error) {
-	var result []Tuple
+	if ch.summaries == nil {
+		ch.summaries = make(map[types.Object]paramSummary)
+	}
+
+	var (
+		result  []Tuple
+		pending []int // indexes in result of tuples that may benefit from rechecking
+	)
 
 	for _, file := range pkg.Syntax {
 		for _, decl := range file.Decls {
@@ -151,40 +296,201 @@ func (ch Checker) CheckPackage(pkg *packages.Package) ([]Tuple, error) {
 			if !ok {
 				continue
 			}
-			m, err := ch.CheckFunc(pkg, fndecl)
+			params, err := ch.checkFunc(pkg, fndecl)
 			if err != nil {
 				return nil, errors.Wrapf(err, "analyzing function %s at %s", fndecl.Name.Name, pkg.Fset.Position(fndecl.Name.Pos()))
 			}
-			result = append(result, Tuple{
-				F: fndecl,
-				P: pkg,
-				M: m,
-			})
+			tparams, err := ch.CheckTypeParams(pkg, fndecl)
+			if err != nil {
+				return nil, errors.Wrapf(err, "analyzing type parameters of %s at %s", fndecl.Name.Name, pkg.Fset.Position(fndecl.Name.Pos()))
+			}
+			if ch.summarize(pkg, fndecl, params) {
+				pending = append(pending, len(result))
+			}
+			t := Tuple{
+				F:      fndecl,
+				P:      pkg,
+				M:      methodMaps(params),
+				Params: params,
+				T:      tparams,
+				I:      ch.CheckIters(pkg, fndecl),
+			}
+			t.dropIters()
+			if ch.TypeSets {
+				t.S = ch.CheckTypeSets(pkg, fndecl)
+			}
+			result = append(result, t)
+		}
+	}
+
+	for progress := true; progress && len(pending) > 0; {
+		progress = false
+
+		var next []int
+		for _, i := range pending {
+			fndecl := result[i].F
+			params, err := ch.checkFunc(pkg, fndecl)
+			if err != nil {
+				return nil, errors.Wrapf(err, "rechecking function %s at %s", fndecl.Name.Name, pkg.Fset.Position(fndecl.Name.Pos()))
+			}
+			if len(methodMaps(params)) > len(result[i].M) {
+				progress = true
+			}
+			if ch.summarize(pkg, fndecl, params) {
+				next = append(next, i)
+			}
+			result[i].M = methodMaps(params)
+			result[i].Params = params
+			result[i].dropIters()
+		}
+		pending = next
+	}
+
+	if ch.FuncLits {
+		// Check literals last,
+		// so the parameters they are passed to
+		// have all been summarized.
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				fndecl, ok := decl.(*ast.FuncDecl)
+				if !ok {
+					continue
+				}
+				lits, err := ch.CheckFuncLits(pkg, fndecl)
+				if err != nil {
+					return nil, errors.Wrapf(err, "analyzing function literals in %s at %s", fndecl.Name.Name, pkg.Fset.Position(fndecl.Name.Pos()))
+				}
+				result = append(result, lits...)
+			}
 		}
 	}
 
 	return result, nil
 }
 
+// summarize records the eligible parameters of fndecl in ch.summaries.
+// It reports whether any parameter was rejected for being passed to a function
+// whose corresponding parameter has a concrete type
+// (which might change when that function is checked).
+func (ch Checker) summarize(pkg *packages.Package, fndecl *ast.FuncDecl, params map[string]ParamResult) bool {
+	var recheck bool
+	for _, field := range fndecl.Type.Params.List {
+		for _, name := range field.Names {
+			res, ok := params[name.Name]
+			if !ok {
+				continue
+			}
+			if res.Reason != nil {
+				if res.Reason.Kind == ReasonConcreteParam {
+					recheck = true
+				}
+				continue
+			}
+			if res.Elems {
+				// Callers can't pass a container where one element is expected.
+				continue
+			}
+			obj, ok := pkg.TypesInfo.Defs[name]
+			if !ok {
+				continue
+			}
+			fn, _ := pkg.TypesInfo.Defs[fndecl.Name].(*types.Func)
+			ch.summaries[obj] = paramSummary{
+				ref: ParamRef{Func: fn, Name: name.Name, F: fndecl, P: pkg},
+				res: res,
+			}
+		}
+	}
+	return recheck
+}
+
 // Tuple is the type of a result from Checker.Check and Checker.CheckPackage.
 type Tuple struct {
 	// F is the function declaration that this result is about.
+	// When L is set,
+	// F is the function declaration containing it.
 	F *ast.FuncDecl
 
+	// L, when set, is the function literal that this result is about.
+	// Only its parameters are checked
+	// (and T, I, and S are empty).
+	// See Checker.CheckFuncLits.
+	L *ast.FuncLit
+
 	// P is the package in which the function declaration appears.
 	P *packages.Package
 
 	// M is a map from the names of function parameters eligible for decoupling
 	// to MethodMaps for each such parameter.
 	M map[string]MethodMap
+
+	// Params maps the name of every named parameter of F (or L)
+	// to the detailed result of checking it.
+	// For parameters not eligible for decoupling,
+	// this includes the reason.
+	Params map[string]ParamResult
+
+	// T maps the names of type parameters of F
+	// whose constraints are wider than F needs
+	// to MethodMaps of the methods F does need
+	// (empty when it needs none).
+	// See Checker.CheckTypeParams.
+	T map[string]MethodMap
+
+	// I maps the names of slice and map parameters of F
+	// that are only ranged over
+	// to the iterator types they could have instead.
+	// Parameters in M are not included.
+	// See Checker.CheckIters.
+	I map[string]Iter
+
+	// S are the type-set constraints
+	// that groups of parameters of basic types could have instead,
+	// when Checker.TypeSets is set.
+	// See Checker.CheckTypeSets.
+	S []TypeSet
+}
+
+// dropIters removes from t.I the parameters in t.M.
+func (t *Tuple) dropIters() {
+	for param := range t.I {
+		if _, ok := t.M[param]; ok {
+			delete(t.I, param)
+		}
+	}
 }
 
 // Pos computes the filename and offset
-// of the function name of the Tuple.
+// of the function name of the Tuple,
+// or of its function literal.
 func (t Tuple) Pos() token.Position {
+	if t.L != nil {
+		return t.P.Fset.Position(t.L.Pos())
+	}
 	return t.P.Fset.Position(t.F.Name.Pos())
 }
 
+// PositionLess tells whether position a comes before position b:
+// in a file whose name sorts earlier,
+// or at a lower offset in the same file.
+// Use it to sort results by their Pos methods.
+func PositionLess(a, b token.Position) bool {
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+	return a.Offset < b.Offset
+}
+
+// FuncType is the type of the function that t is about:
+// that of L if it is set,
+// otherwise that of F.
+func (t Tuple) FuncType() *ast.FuncType {
+	if t.L != nil {
+		return t.L.Type
+	}
+	return t.F.Type
+}
+
 // MethodMap maps a set of method names to their calling signatures.
 type MethodMap = map[string]*types.Signature
 
@@ -192,50 +498,177 @@ type MethodMap = map[string]*types.Signature
 // which should appear in the given package,
 // which should be one of the packages contained in the Checker.
 // The result is a map from parameter names eligible for decoupling to MethodMaps.
+//
+// A parameter passed to another function
+// is eligible only if that function has already been checked
+// (by CheckPackage)
+// and its corresponding parameter found eligible,
+// or if that was supplied with Assume.
 func (ch Checker) CheckFunc(pkg *packages.Package, fndecl *ast.FuncDecl) (map[string]MethodMap, error) {
-	result := make(map[string]MethodMap)
+	params, err := ch.checkFunc(pkg, fndecl)
+	if err != nil {
+		return nil, err
+	}
+	return methodMaps(params), nil
+}
+
+func (ch Checker) checkFunc(pkg *packages.Package, fndecl *ast.FuncDecl) (map[string]ParamResult, error) {
+	result := make(map[string]ParamResult)
 	for _, field := range fndecl.Type.Params.List {
 		for _, name := range field.Names {
 			if name.Name == "_" {
 				continue
 			}
 
-			nameResult, err := ch.CheckParam(pkg, fndecl, name)
+			nameResult, err := ch.CheckParamDetail(pkg, fndecl, name)
 			if err != nil {
 				return nil, errors.Wrapf(err, "analyzing parameter %s of %s", name.Name, fndecl.Name.Name)
 			}
-			if len(nameResult) != 0 {
-				result[name.Name] = nameResult
-			}
+			result[name.Name] = nameResult
 		}
 	}
 	return result, nil
 }
 
+func methodMaps(params map[string]ParamResult) map[string]MethodMap {
+	result := make(map[string]MethodMap)
+	for name, res := range params {
+		if len(res.Methods) != 0 {
+			result[name] = res.Methods
+		}
+	}
+	return result
+}
+
 // CheckParam checks a single named parameter in a given function declaration,
 // which must apepar in the given package,
 // which should be one of the packages in the Checker.
 // The result is a MethodMap for the parameter,
 // and may be nil if the parameter is not eligible for decoupling.
-func (ch Checker) CheckParam(pkg *packages.Package, fndecl *ast.FuncDecl, name *ast.Ident) (_ MethodMap, err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			if e, ok := r.(error); ok {
-				var d derr
-				if errors.As(e, &d) {
-					err = d
-					return
-				}
-			}
-			panic(r)
-		}
-	}()
+func (ch Checker) CheckParam(pkg *packages.Package, fndecl *ast.FuncDecl, name *ast.Ident) (MethodMap, error) {
+	res, err := ch.CheckParamDetail(pkg, fndecl, name)
+	return res.Methods, err
+}
+
+// checkParamEngine analyzes the uses of a parameter
+// with the Checker's Engine.
+func (ch Checker) checkParamEngine(pkg *packages.Package, fndecl *ast.FuncDecl, name *ast.Ident, obj types.Object) (ParamResult, error) {
+	if ch.Engine == EngineSSA {
+		return ch.checkParamSSA(pkg, fndecl, obj)
+	}
+	return ch.checkParamSyntax(pkg, fndecl, name, obj, nil)
+}
+
+// CheckParamDetail is like CheckParam
+// but produces a ParamResult,
+// which includes the reason the parameter is not eligible for decoupling
+// when that is the case.
+func (ch Checker) CheckParamDetail(pkg *packages.Package, fndecl *ast.FuncDecl, name *ast.Ident) (_ ParamResult, err error) {
+	defer recoverDerr(&err)
 
 	obj, ok := pkg.TypesInfo.Defs[name]
 	if !ok {
-		return nil, fmt.Errorf("no def found for %s", name.Name)
+		return ParamResult{}, fmt.Errorf("no def found for %s", name.Name)
+	}
+	if fndecl.Body == nil {
+		// A function implemented outside Go (e.g. in assembly).
+		return ParamResult{Reason: &Reason{Kind: ReasonUnsupported, Pos: pkg.Fset.Position(fndecl.Pos()), Detail: "no function body"}}, nil
+	}
+
+	if _, ok := obj.Type().(*types.TypeParam); ok {
+		// Changing the type of such a parameter would make the type parameter uninferable at call sites.
+		return ParamResult{Reason: &Reason{Kind: ReasonUnsupported, Pos: pkg.Fset.Position(name.Pos()), Detail: "type parameter"}}, nil
+	}
+
+	res, err := ch.checkParamEngine(pkg, fndecl, name, obj)
+	if err != nil {
+		return res, err
+	}
+	if res.Reason != nil && elemType(obj.Type()) != nil {
+		// Maybe the parameter's elements can be decoupled instead.
+		eres, err := ch.checkElems(pkg, fndecl, name, obj)
+		if err != nil {
+			return ParamResult{}, err
+		}
+		if eres.Reason == nil {
+			res = eres
+		}
+	}
+	if res.Reason != nil {
+		return res, nil
+	}
+	if reason := ch.fixedSignature(pkg, fndecl); reason != nil {
+		return ParamResult{Reason: reason}, nil
+	}
+
+	// The parameter's value is in obj and its aliases
+	// (or, for its elements, in its aliases and its index and receive expressions).
+	var (
+		typ  = obj.Type()
+		objs []types.Object
+	)
+	if res.Elems {
+		typ = elemType(typ)
+	} else {
+		objs = append(objs, obj)
+	}
+	for _, alias := range res.Aliases {
+		if aobj := pkg.TypesInfo.Defs[alias]; aobj != nil {
+			objs = append(objs, aobj)
+		}
+	}
+	isObj := func(expr ast.Expr) bool {
+		if res.Elems && isElemExpr(pkg.TypesInfo, expr, obj) {
+			return true
+		}
+		id, ok := ast.Unparen(expr).(*ast.Ident)
+		return ok && slices.Contains(objs, pkg.TypesInfo.ObjectOf(id))
+	}
+
+	res.Upgrades = ch.lostUpgrades(pkg, fndecl, typ, isObj, res.Methods)
+	res.Generic = genericOK(fndecl, isObj) && !ch.uninferable.Has(obj)
+
+	return res, nil
+}
+
+// fixedSignature tells why the signature of fndecl cannot change,
+// if it cannot.
+func (ch Checker) fixedSignature(pkg *packages.Package, fndecl *ast.FuncDecl) *Reason {
+	fnobj, ok := pkg.TypesInfo.Defs[fndecl.Name].(*types.Func)
+	if !ok {
+		return nil
 	}
 
+	// A function used as a function value
+	// must keep the signature of the function type it's used as.
+	if use, ok := ch.funcValues[fnobj]; ok {
+		detail := types.TypeString(use.typ, types.RelativeTo(pkg.Types))
+		return &Reason{Kind: ReasonFuncValue, Pos: use.pos, Detail: detail}
+	}
+
+	// A method whose type is used as an interface containing it
+	// must keep its signature.
+	if fndecl.Recv != nil {
+		if use, ok := ch.constrainingInterface(fnobj); ok {
+			detail := types.TypeString(use.intf, types.RelativeTo(pkg.Types))
+			return &Reason{Kind: ReasonInterfaceMethod, Pos: use.pos, Detail: detail}
+		}
+	}
+
+	return nil
+}
+
+// checkParamSyntax is the EngineSyntax implementation of CheckParamDetail.
+// When elemsOf is non-nil,
+// obj stands for the elements of that parameter
+// (see checkElems).
+func (ch Checker) checkParamSyntax(pkg *packages.Package, fndecl *ast.FuncDecl, name *ast.Ident, obj, elemsOf types.Object) (ParamResult, error) {
+	return ch.checkParamBody(pkg, &funcDeclOrLit{decl: fndecl}, fndecl.Body, name, obj, elemsOf), nil
+}
+
+// checkParamBody analyzes the uses of the parameter obj
+// of fn, a function declaration or literal, in its body.
+func (ch Checker) checkParamBody(pkg *packages.Package, fn *funcDeclOrLit, body *ast.BlockStmt, name *ast.Ident, obj, elemsOf types.Object) ParamResult {
 	var (
 		intf = getType[*types.Interface](obj.Type())
 		mm   MethodMap
@@ -247,27 +680,36 @@ func (ch Checker) CheckParam(pkg *packages.Package, fndecl *ast.FuncDecl, name *
 	a := analyzer{
 		name:          name,
 		obj:           obj,
+		elemsOf:       elemsOf,
 		pkg:           pkg,
 		objmethods:    mm,
 		methods:       make(MethodMap),
-		enclosingFunc: &funcDeclOrLit{decl: fndecl},
+		uses:          make(map[string][]Use),
+		summaries:     ch.summaries,
+		enclosingFunc: fn,
 		debug:         ch.Verbose,
 	}
-	a.debugf("fn %s param %s", fndecl.Name.Name, name.Name)
-	for _, stmt := range fndecl.Body.List {
+	if fn.decl != nil {
+		a.debugf("fn %s param %s", fn.decl.Name.Name, name.Name)
+	} else {
+		a.debugf("func literal at %s param %s", a.pos(fn.lit), name.Name)
+	}
+	aliases := a.findAliases(body)
+	for _, stmt := range body.List {
 		if !a.stmt(stmt) {
-			return nil, nil
+			return ParamResult{Reason: a.reason}
 		}
 	}
 
-	if len(a.objmethods) > 0 {
-		if len(a.methods) < len(a.objmethods) {
-			// A smaller interface will do.
-			return a.methods, nil
-		}
-		return nil, nil
+	if len(a.objmethods) > 0 && len(a.methods) >= len(a.objmethods) {
+		return ParamResult{Reason: &Reason{Kind: ReasonNoNarrower}}
+	}
+	if len(a.methods) == 0 {
+		return ParamResult{Reason: &Reason{Kind: ReasonNoMethods}}
 	}
-	return a.methods, nil
+
+	// A smaller interface will do.
+	return ParamResult{Methods: a.methods, Uses: a.uses, Requires: a.requires, Aliases: aliases}
 }
 
 // NameForMethods takes a MethodMap
@@ -276,12 +718,74 @@ func (ch Checker) CheckParam(pkg *packages.Package, fndecl *ast.FuncDecl, name *
 // If there are multiple such interfaces,
 // one is chosen arbitrarily.
 func (ch Checker) NameForMethods(inp MethodMap) string {
-	for name, mm := range ch.namedInterfaces {
-		if sameMethodMaps(mm, inp) {
-			return name
+	name, _ := ch.interfaceForMethods(inp, nil)
+	return name
+}
+
+// interfaceForMethods is like NameForMethods
+// but also returns the type-name object for the interface.
+// If from is not nil,
+// only interfaces that can be used in that package are considered
+// (see importable).
+// When there are multiple matches,
+// the one whose name sorts first is chosen,
+// so that the result is stable from one run to the next.
+func (ch Checker) interfaceForMethods(inp MethodMap, from *types.Package) (string, *types.TypeName) {
+	var (
+		bestName string
+		bestObj  *types.TypeName
+	)
+	for name, ni := range ch.namedInterfaces {
+		if !sameMethodMaps(ni.mm, inp) {
+			continue
+		}
+		if from != nil && !ch.importable(ni.obj.Pkg(), from) {
+			continue
+		}
+		if bestObj == nil || name < bestName {
+			bestName, bestObj = name, ni.obj
 		}
 	}
-	return ""
+	return bestName, bestObj
+}
+
+// importable tells whether the package from
+// can refer to the declarations in tpkg:
+// whether it is tpkg,
+// or it can import tpkg.
+// It cannot import a main package,
+// an external test package,
+// or a package that imports it,
+// directly or indirectly,
+// since that would be an import cycle.
+func (ch Checker) importable(tpkg, from *types.Package) bool {
+	if tpkg == from {
+		return true
+	}
+	if tpkg.Name() == "main" || strings.HasSuffix(tpkg.Path(), "_test") {
+		return false
+	}
+	pkg, ok := ch.allPkgs[tpkg.Path()]
+	if !ok {
+		return true
+	}
+	var (
+		seen    = set.New[*packages.Package]()
+		imports func(*packages.Package) bool
+	)
+	imports = func(pkg *packages.Package) bool {
+		if seen.Has(pkg) {
+			return false
+		}
+		seen.Add(pkg)
+		for _, ipkg := range pkg.Imports {
+			if ipkg.PkgPath == from.Path() || imports(ipkg) {
+				return true
+			}
+		}
+		return false
+	}
+	return !imports(pkg)
 }
 
 type funcDeclOrLit struct {
@@ -294,14 +798,38 @@ type analyzer struct {
 	obj  types.Object
 	pkg  *packages.Package
 
+	// elemsOf, when set, is the container parameter whose elements obj stands for.
+	// Its index and receive expressions count as our object.
+	elemsOf types.Object
+
+	// aliases is the set of local variables that obj is copied into.
+	// They are analyzed along with obj.
+	// See findAliases.
+	aliases set.Of[types.Object]
+
+	// summaries is input: the results for parameters of functions already checked.
+	summaries map[types.Object]paramSummary
+
+	// requires is output: the parameters of other functions
+	// whose results were used in computing methods.
+	requires []ParamRef
+
 	// objmethods is input: the methodmap for obj's type,
 	// if that's an interface type.
 	// methods is output: the set of methods actually used.
 	objmethods, methods MethodMap
 
+	// uses is output: where each method in methods is required.
+	uses map[string][]Use
+
 	enclosingFunc       *funcDeclOrLit
 	enclosingSwitchStmt *ast.SwitchStmt
 
+	// reason is output:
+	// why obj is not eligible for decoupling,
+	// set by the first call to reject.
+	reason *Reason
+
 	level int
 	debug bool
 }
@@ -329,16 +857,37 @@ func (a *analyzer) getSig(expr ast.Expr) *types.Signature {
 	return getType[*types.Signature](a.pkg.TypesInfo.Types[expr].Type)
 }
 
-// Does expr denote the object in a?
+// Does expr denote the object in a
+// (or one of its aliases)?
+// When the object is a struct field,
+// it is denoted by selector expressions
+// (and the identifiers naming it in composite literals do not count).
 func (a *analyzer) isObj(expr ast.Expr) bool {
 	switch expr := expr.(type) {
 	case *ast.Ident:
-		obj := a.pkg.TypesInfo.Uses[expr]
-		return obj == a.obj
+		obj := a.pkg.TypesInfo.ObjectOf(expr)
+		if obj == nil {
+			return false
+		}
+		if v, ok := a.obj.(*types.Var); ok && v.IsField() {
+			return a.aliases.Has(obj)
+		}
+		return obj == a.obj || a.aliases.Has(obj)
 
 	case *ast.ParenExpr:
 		return a.isObj(expr.X)
 
+	case *ast.SelectorExpr:
+		sel, ok := a.pkg.TypesInfo.Selections[expr]
+		if !ok || sel.Kind() != types.FieldVal {
+			return false
+		}
+		v, ok := sel.Obj().(*types.Var)
+		return ok && v.Origin() == a.obj
+
+	case *ast.IndexExpr, *ast.UnaryExpr:
+		return a.elemsOf != nil && isElemExpr(a.pkg.TypesInfo, expr, a.elemsOf)
+
 	default:
 		return false
 	}
@@ -367,21 +916,38 @@ func (a *analyzer) stmt(stmt ast.Stmt) (ok bool) {
 			}
 		}
 		for i, rhs := range stmt.Rhs {
-			// xxx do a recursive analysis of how this var is used!
-			if a.isObj(rhs) && stmt.Tok != token.DEFINE {
-				if stmt.Tok != token.ASSIGN {
-					// Reject OP=
+			if a.isAddr(rhs) && i < len(stmt.Lhs) {
+				if isBlank(stmt.Lhs[i]) {
+					continue
+				}
+				if !a.addr(rhs, a.pkg.TypesInfo.TypeOf(stmt.Lhs[i])) {
 					return false
 				}
-				tv, ok := a.pkg.TypesInfo.Types[stmt.Lhs[i]]
-				if !ok {
+				continue
+			}
+			if a.isObj(rhs) {
+				if stmt.Tok != token.ASSIGN && stmt.Tok != token.DEFINE {
+					// Reject OP=
+					return a.reject(rhs, ReasonArithmetic, stmt.Tok.String())
+				}
+				if i >= len(stmt.Lhs) {
+					panic(errf("cannot assign %d value(s) to %d variable(s) at %s", len(stmt.Rhs), len(stmt.Lhs), a.pos(stmt)))
+				}
+				lhs := stmt.Lhs[i]
+				if a.isObj(lhs) || isBlank(lhs) {
+					// Copying our object into an alias (whose uses are analyzed too),
+					// or discarding it.
+					continue
+				}
+				typ := a.pkg.TypesInfo.TypeOf(lhs)
+				if typ == nil {
 					panic(errf("no type info for lvalue %d in assignment at %s", i, a.pos(stmt)))
 				}
-				intf := getType[*types.Interface](tv.Type)
+				intf := getType[*types.Interface](typ)
 				if intf == nil {
-					return false
+					return a.reject(rhs, ReasonConcreteAssign, "")
 				}
-				a.addMethods(intf)
+				a.addMethods(intf, rhs, "assignment as "+a.typeString(typ))
 				continue
 			}
 			if !a.expr(rhs) {
@@ -408,7 +974,7 @@ func (a *analyzer) stmt(stmt ast.Stmt) (ok bool) {
 					panic(errf("case clause with no enclosing switch statement at %s", a.pos(stmt)))
 				}
 				if a.enclosingSwitchStmt.Tag == nil {
-					return false // would require our obj to evaluate as a boolean
+					return a.reject(expr, ReasonCondition, "") // would require our obj to evaluate as a boolean
 				}
 				tv, ok := a.pkg.TypesInfo.Types[a.enclosingSwitchStmt.Tag]
 				if !ok {
@@ -418,7 +984,7 @@ func (a *analyzer) stmt(stmt ast.Stmt) (ok bool) {
 				if !types.AssignableTo(t1, t2) && !types.AssignableTo(t2, t1) {
 					// "In any comparison, the first operand must be assignable to the type of the second operand, or vice versa."
 					// https://go.dev/ref/spec#Comparison_operators
-					return false
+					return a.reject(expr, ReasonConcreteComparison, "")
 				}
 				continue
 			}
@@ -452,13 +1018,13 @@ func (a *analyzer) stmt(stmt ast.Stmt) (ok bool) {
 		return a.expr(stmt.Call)
 
 	case *ast.ExprStmt:
-		return !a.isObjOrNotExpr(stmt.X) // a.isObj(stmt.X) probably can't happen in a well-formed program.
+		return !a.isObjOrNotExpr(stmt.X, ReasonUnsupported, "expression statement") // a.isObj(stmt.X) probably can't happen in a well-formed program.
 
 	case *ast.ForStmt:
 		if !a.stmt(stmt.Init) {
 			return false
 		}
-		if a.isObjOrNotExpr(stmt.Cond) {
+		if a.isObjOrNotExpr(stmt.Cond, ReasonCondition, "") {
 			return false
 		}
 		if !a.stmt(stmt.Post) {
@@ -473,7 +1039,7 @@ func (a *analyzer) stmt(stmt ast.Stmt) (ok bool) {
 		if !a.stmt(stmt.Init) {
 			return false
 		}
-		if a.isObjOrNotExpr(stmt.Cond) {
+		if a.isObjOrNotExpr(stmt.Cond, ReasonCondition, "") {
 			return false
 		}
 		if !a.stmt(stmt.Body) {
@@ -482,7 +1048,7 @@ func (a *analyzer) stmt(stmt ast.Stmt) (ok bool) {
 		return a.stmt(stmt.Else)
 
 	case *ast.IncDecStmt:
-		return !a.isObjOrNotExpr(stmt.X)
+		return !a.isObjOrNotExpr(stmt.X, ReasonArithmetic, stmt.Tok.String())
 
 	case *ast.LabeledStmt:
 		return a.stmt(stmt.Stmt)
@@ -490,14 +1056,14 @@ func (a *analyzer) stmt(stmt ast.Stmt) (ok bool) {
 	case *ast.RangeStmt:
 		// As with AssignStmt,
 		// if our object appears on the lhs we don't care.
-		if a.isObjOrNotExpr(stmt.X) {
+		if a.isObjOrNotExpr(stmt.X, ReasonRange, "") {
 			return false
 		}
 		return a.stmt(stmt.Body)
 
 	case *ast.ReturnStmt:
 		for i, expr := range stmt.Results {
-			if a.isObj(expr) {
+			if a.isObj(expr) || a.isAddr(expr) {
 				typ, fpos, ok := a.enclosingFuncInfo()
 				if !ok {
 					panic(errf("no type info for function containing return statement at %s", a.pos(expr)))
@@ -510,11 +1076,17 @@ func (a *analyzer) stmt(stmt ast.Stmt) (ok bool) {
 					panic(errf("cannot return %d value(s) from %d-value-returning function at %s", i+1, sig.Results().Len(), a.pos(stmt)))
 				}
 				resultvar := sig.Results().At(i)
+				if a.isAddr(expr) {
+					if !a.addr(expr, resultvar.Type()) {
+						return false
+					}
+					continue
+				}
 				intf := getType[*types.Interface](resultvar.Type())
 				if intf == nil {
-					return false
+					return a.reject(expr, ReasonConcreteReturn, "")
 				}
-				a.addMethods(intf)
+				a.addMethods(intf, expr, "return as "+a.typeString(resultvar.Type()))
 				continue
 			}
 			if !a.expr(expr) {
@@ -527,10 +1099,10 @@ func (a *analyzer) stmt(stmt ast.Stmt) (ok bool) {
 		return a.stmt(stmt.Body)
 
 	case *ast.SendStmt:
-		if a.isObjOrNotExpr(stmt.Chan) {
+		if a.isObjOrNotExpr(stmt.Chan, ReasonChannel, "") {
 			return false
 		}
-		if a.isObj(stmt.Value) {
+		if a.isObj(stmt.Value) || a.isAddr(stmt.Value) {
 			tv, ok := a.pkg.TypesInfo.Types[stmt.Chan]
 			if !ok {
 				panic(errf("no type info for channel in send statement at %s", a.pos(stmt)))
@@ -539,11 +1111,14 @@ func (a *analyzer) stmt(stmt ast.Stmt) (ok bool) {
 			if chtyp == nil {
 				panic(errf("got %T, want channel for type of channel in send statement at %s", tv.Type, a.pos(stmt)))
 			}
+			if a.isAddr(stmt.Value) {
+				return a.addr(stmt.Value, chtyp.Elem())
+			}
 			intf := getType[*types.Interface](chtyp.Elem())
 			if intf == nil {
-				return false
+				return a.reject(stmt.Value, ReasonConcreteSend, "")
 			}
-			a.addMethods(intf)
+			a.addMethods(intf, stmt.Value, "send as "+a.typeString(chtyp.Elem()))
 			return true
 		}
 		return a.expr(stmt.Value)
@@ -559,7 +1134,7 @@ func (a *analyzer) stmt(stmt ast.Stmt) (ok bool) {
 		return a.stmt(stmt.Body)
 	}
 
-	return false
+	return a.reject(stmt, ReasonUnsupported, fmt.Sprintf("%T", stmt))
 }
 
 func (a *analyzer) pos(p interface{ Pos() token.Pos }) token.Position {
@@ -571,8 +1146,23 @@ type methoder interface {
 	Method(int) *types.Func
 }
 
-func (a *analyzer) addMethods(intf methoder) {
+// addMethods adds the methods of intf to a.methods,
+// recording the use of our object at p
+// (described by via)
+// as the reason for requiring them.
+func (a *analyzer) addMethods(intf methoder, p interface{ Pos() token.Pos }, via string) {
 	addMethodsToMap(intf, a.methods)
+	for i := 0; i < intf.NumMethods(); i++ {
+		a.addUse(intf.Method(i).Name(), p, via)
+	}
+}
+
+func (a *analyzer) addUse(method string, p interface{ Pos() token.Pos }, via string) {
+	a.uses[method] = append(a.uses[method], Use{Pos: a.pos(p), Via: via})
+}
+
+func (a *analyzer) typeString(typ types.Type) string {
+	return types.TypeString(typ, types.RelativeTo(a.pkg.Types))
 }
 
 func addMethodsToMap(intf methoder, mm MethodMap) {
@@ -616,37 +1206,47 @@ func (a *analyzer) expr(expr ast.Expr) (ok bool) {
 				}
 				intf := getType[*types.Interface](tv.Type)
 				if intf == nil {
-					return false
+					return a.reject(expr, ReasonConcreteComparison, "")
 				}
-				a.addMethods(intf)
+				a.addMethods(intf, expr, "comparison with "+a.typeString(tv.Type))
 				// Continue below.
 
 			default:
-				return false
+				return a.reject(expr, ReasonArithmetic, expr.Op.String())
 			}
 		}
 
 		return a.expr(expr.X) && a.expr(expr.Y)
 
 	case *ast.CallExpr:
-		if a.isObjOrNotExpr(expr.Fun) {
+		if a.isObjOrNotExpr(expr.Fun, ReasonCall, "") {
 			return false
 		}
 		for i, arg := range expr.Args {
+			if a.isAddr(arg) {
+				if !a.addr(arg, a.argType(expr, i)) {
+					return false
+				}
+				continue
+			}
 			if a.isObj(arg) {
 				if i == len(expr.Args)-1 && expr.Ellipsis != token.NoPos {
 					// This is "obj..." using our object, requiring it to be a slice.
-					return false
+					return a.reject(arg, ReasonVariadic, "")
 				}
 				tv, ok := a.pkg.TypesInfo.Types[expr.Fun]
 				if !ok {
 					panic(errf("no type info for function in call expression at %s", a.pos(expr)))
 				}
+				if tv.IsType() {
+					// This is a type conversion expression; e.g. int(x).
+					return a.reject(expr, ReasonConversion, types.ExprString(expr.Fun))
+				}
 				sig := getType[*types.Signature](tv.Type)
 				if sig == nil {
 					// This could be a type conversion expression; e.g. int(x).
 					if len(expr.Args) == 1 {
-						return false
+						return a.reject(expr, ReasonConversion, types.ExprString(expr.Fun))
 					}
 					panic(errf("got %T, want *types.Signature for type of function in call expression at %s", tv.Type, a.pos(expr)))
 				}
@@ -669,9 +1269,26 @@ func (a *analyzer) expr(expr ast.Expr) (ok bool) {
 				}
 				intf := getType[*types.Interface](ptype)
 				if intf == nil {
-					return false
+					if constraint := a.typeParamConstraint(expr.Fun, i); constraint != nil {
+						// The callee is generic,
+						// and our object can be passed to it as any type satisfying the constraint.
+						a.addMethods(constraint, arg, types.ExprString(expr.Fun))
+						continue
+					}
+					summary, ok := a.calleeParam(expr.Fun, i)
+					if !ok {
+						return a.reject(arg, ReasonConcreteParam, types.ExprString(expr.Fun))
+					}
+					// The callee's parameter is itself eligible for decoupling.
+					// Our object needs whatever methods it needs.
+					for name, sig := range summary.res.Methods {
+						a.methods[name] = sig
+						a.addUse(name, arg, types.ExprString(expr.Fun))
+					}
+					a.requires = append(a.requires, summary.ref)
+					continue
 				}
-				a.addMethods(intf)
+				a.addMethods(intf, arg, types.ExprString(expr.Fun))
 				continue
 			}
 			if !a.expr(arg) {
@@ -691,13 +1308,13 @@ func (a *analyzer) expr(expr ast.Expr) (ok bool) {
 					}
 					mapType := getType[*types.Map](tv.Type)
 					if mapType == nil {
-						return false
+						return a.reject(kv.Key, ReasonConcreteElement, "")
 					}
 					intf := getType[*types.Interface](mapType.Key())
 					if intf == nil {
-						return false
+						return a.reject(kv.Key, ReasonConcreteElement, "")
 					}
-					a.addMethods(intf)
+					a.addMethods(intf, kv.Key, "map key as "+a.typeString(mapType.Key()))
 				} else if !a.expr(kv.Key) {
 					return false
 				}
@@ -742,14 +1359,14 @@ func (a *analyzer) expr(expr ast.Expr) (ok bool) {
 						elemType = literalType.Elem()
 
 					default:
-						return false
+						return a.reject(kv.Value, ReasonConcreteElement, "")
 					}
 
 					intf := getType[*types.Interface](elemType)
 					if intf == nil {
-						return false
+						return a.reject(kv.Value, ReasonConcreteElement, "")
 					}
-					a.addMethods(intf)
+					a.addMethods(intf, kv.Value, "element as "+a.typeString(elemType))
 
 				} else if !a.expr(kv.Value) {
 					return false
@@ -785,9 +1402,9 @@ func (a *analyzer) expr(expr ast.Expr) (ok bool) {
 
 				intf := getType[*types.Interface](elemType)
 				if intf == nil {
-					return false
+					return a.reject(elt, ReasonConcreteElement, "")
 				}
-				a.addMethods(intf)
+				a.addMethods(intf, elt, "element as "+a.typeString(elemType))
 
 				continue
 			}
@@ -798,7 +1415,7 @@ func (a *analyzer) expr(expr ast.Expr) (ok bool) {
 		return true
 
 	case *ast.Ellipsis:
-		return !a.isObjOrNotExpr(expr.Elt)
+		return !a.isObjOrNotExpr(expr.Elt, ReasonUnsupported, "ellipsis")
 
 	case *ast.FuncLit:
 		return a.funcLit(expr)
@@ -807,7 +1424,7 @@ func (a *analyzer) expr(expr ast.Expr) (ok bool) {
 		return true
 
 	case *ast.IndexExpr:
-		if a.isObjOrNotExpr(expr.X) {
+		if a.isObjOrNotExpr(expr.X, ReasonIndex, "") {
 			return false
 		}
 		if a.isObj(expr.Index) {
@@ -820,23 +1437,23 @@ func (a *analyzer) expr(expr ast.Expr) (ok bool) {
 			}
 			mapType := getType[*types.Map](tv.Type)
 			if mapType == nil {
-				return false
+				return a.reject(expr.Index, ReasonConcreteElement, "")
 			}
 			intf := getType[*types.Interface](mapType.Key())
 			if intf == nil {
-				return false
+				return a.reject(expr.Index, ReasonConcreteElement, "")
 			}
-			a.addMethods(intf)
+			a.addMethods(intf, expr.Index, "map key as "+a.typeString(mapType.Key()))
 			return true
 		}
 		return a.expr(expr.Index)
 
 	case *ast.IndexListExpr:
-		if a.isObjOrNotExpr(expr.X) {
+		if a.isObjOrNotExpr(expr.X, ReasonIndex, "") {
 			return false
 		}
 		for _, idx := range expr.Indices {
-			if a.isObjOrNotExpr(idx) {
+			if a.isObjOrNotExpr(idx, ReasonUnsupported, "type argument") {
 				return false
 			}
 		}
@@ -852,26 +1469,27 @@ func (a *analyzer) expr(expr ast.Expr) (ok bool) {
 		if a.isObj(expr.X) {
 			if sig := a.getSig(expr); sig != nil {
 				a.methods[expr.Sel.Name] = sig
+				a.addUse(expr.Sel.Name, expr.Sel, "")
 				return true
 			}
-			return false
+			return a.reject(expr, ReasonFieldAccess, "."+expr.Sel.Name)
 		}
 		return a.expr(expr.X)
 
 	case *ast.SliceExpr:
-		if a.isObjOrNotExpr(expr.X) {
+		if a.isObjOrNotExpr(expr.X, ReasonIndex, "") {
 			return false
 		}
-		if a.isObjOrNotExpr(expr.Low) {
+		if a.isObjOrNotExpr(expr.Low, ReasonIndex, "") {
 			return false
 		}
-		if a.isObjOrNotExpr(expr.High) {
+		if a.isObjOrNotExpr(expr.High, ReasonIndex, "") {
 			return false
 		}
-		return !a.isObjOrNotExpr(expr.Max)
+		return !a.isObjOrNotExpr(expr.Max, ReasonIndex, "")
 
 	case *ast.StarExpr:
-		return !a.isObjOrNotExpr(expr.X)
+		return !a.isObjOrNotExpr(expr.X, ReasonUnaryOp, "*")
 
 	case *ast.TypeAssertExpr:
 		// Can skip expr.Type.
@@ -879,7 +1497,12 @@ func (a *analyzer) expr(expr ast.Expr) (ok bool) {
 
 	case *ast.UnaryExpr:
 		if a.isObj(expr.X) {
-			return expr.Op == token.AND
+			if expr.Op == token.AND {
+				// Taking our object's address
+				// in a context not handled by the caller.
+				return a.addr(expr, nil)
+			}
+			return a.reject(expr, ReasonUnaryOp, expr.Op.String())
 		}
 		return a.expr(expr.X)
 	}
@@ -887,13 +1510,141 @@ func (a *analyzer) expr(expr ast.Expr) (ok bool) {
 	return true
 }
 
-func (a *analyzer) isObjOrNotExpr(expr ast.Expr) bool {
+// isAddr tells whether expr takes the address of our object
+// (or one of its aliases).
+func (a *analyzer) isAddr(expr ast.Expr) bool {
+	u, ok := ast.Unparen(expr).(*ast.UnaryExpr)
+	return ok && u.Op == token.AND && a.isObj(u.X)
+}
+
+// addr handles expr,
+// which takes the address of our object,
+// flowing into a destination of type dst
+// (nil if unknown).
+// Once our object is decoupled,
+// its address is a pointer to an interface,
+// which has no methods
+// and is no longer the concrete pointer type.
+// So the only safe destination is an interface requiring no methods,
+// like any,
+// and only if our object is already an interface:
+// otherwise whatever uses the pointer
+// (like json.Unmarshal, via reflection)
+// sees a different kind of pointee.
+func (a *analyzer) addr(expr ast.Expr, dst types.Type) bool {
+	if dst == nil {
+		dst = a.pkg.TypesInfo.TypeOf(expr)
+	}
+	if intf := getType[*types.Interface](dst); intf != nil && intf.NumMethods() == 0 && types.IsInterface(a.obj.Type()) {
+		return true
+	}
+	return a.reject(expr, ReasonAddress, a.typeString(dst))
+}
+
+// argType is the type of the destination of argument i in call,
+// or nil if it has none
+// (as when it is the final argument of a variadic call with "...").
+func (a *analyzer) argType(call *ast.CallExpr, i int) types.Type {
+	tv, ok := a.pkg.TypesInfo.Types[call.Fun]
+	if !ok {
+		return nil
+	}
+	if tv.IsType() {
+		return tv.Type
+	}
+	sig := getType[*types.Signature](tv.Type)
+	if sig == nil {
+		return nil
+	}
+	params := sig.Params()
+	switch {
+	case sig.Variadic() && i >= params.Len()-1:
+		if call.Ellipsis != token.NoPos {
+			return nil
+		}
+		if slice, ok := params.At(params.Len() - 1).Type().(*types.Slice); ok {
+			return slice.Elem()
+		}
+		return nil
+	case i < params.Len():
+		return params.At(i).Type()
+	}
+	return nil
+}
+
+// typeParamConstraint finds the constraint on parameter i
+// of the generic function denoted by fun,
+// if our object can be passed to it as any type satisfying that constraint
+// (see the typeParamConstraint function).
+// Explicit instantiations (like G[*os.File]) are excluded,
+// since they fix the parameter's type.
+func (a *analyzer) typeParamConstraint(fun ast.Expr, i int) *types.Interface {
+	switch ast.Unparen(fun).(type) {
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		return nil
+	}
+	_, fn := funcRef(a.pkg.TypesInfo, fun)
+	if fn == nil {
+		return nil
+	}
+	return typeParamConstraint(fn.Type(), i)
+}
+
+// calleeParam finds the summary for parameter i
+// of the function denoted by fun,
+// if that function has already been checked
+// and its parameter found eligible for decoupling.
+func (a *analyzer) calleeParam(fun ast.Expr, i int) (paramSummary, bool) {
+	var id *ast.Ident
+	switch fun := ast.Unparen(fun).(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	case *ast.IndexExpr:
+		return a.calleeParam(fun.X, i)
+	case *ast.IndexListExpr:
+		return a.calleeParam(fun.X, i)
+	default:
+		return paramSummary{}, false
+	}
+	fn, ok := a.pkg.TypesInfo.Uses[id].(*types.Func)
+	if !ok {
+		return paramSummary{}, false
+	}
+	sig := fn.Origin().Type().(*types.Signature)
+	if sig.Variadic() && i >= sig.Params().Len()-1 {
+		return paramSummary{}, false
+	}
+	if i >= sig.Params().Len() {
+		return paramSummary{}, false
+	}
+	summary, ok := a.summaries[sig.Params().At(i)]
+	return summary, ok
+}
+
+// isObjOrNotExpr tells whether expr either is our object
+// (in a position where that makes it ineligible for decoupling,
+// for the given reason)
+// or is an expression that uses our object in an ineligible way.
+func (a *analyzer) isObjOrNotExpr(expr ast.Expr, kind ReasonKind, detail string) bool {
 	if a.isObj(expr) {
+		a.reject(expr, kind, detail)
 		return true
 	}
 	return !a.expr(expr)
 }
 
+// reject records the reason our object is ineligible for decoupling
+// (unless one was recorded already)
+// and returns false.
+func (a *analyzer) reject(p interface{ Pos() token.Pos }, kind ReasonKind, detail string) bool {
+	if a.reason == nil {
+		a.reason = &Reason{Kind: kind, Pos: a.pos(p), Detail: detail}
+	}
+	return false
+}
+
 func (a *analyzer) decl(decl ast.Decl) bool {
 	switch decl := decl.(type) {
 	case *ast.GenDecl:
@@ -905,9 +1656,22 @@ func (a *analyzer) decl(decl ast.Decl) bool {
 			if !ok {
 				panic(errf("got %T, want *ast.ValueSpec in variable declaration at %s", spec, a.pos(decl)))
 			}
-			for _, val := range valspec.Values {
+			for i, val := range valspec.Values {
+				if a.isAddr(val) && i < len(valspec.Names) {
+					dst := a.pkg.TypesInfo.TypeOf(valspec.Names[i])
+					if valspec.Type != nil {
+						dst = a.pkg.TypesInfo.TypeOf(valspec.Type)
+					}
+					if !a.addr(val, dst) {
+						return false
+					}
+					continue
+				}
 				if a.isObj(val) {
-					if valspec.Type == nil {
+					if valspec.Type == nil || (i < len(valspec.Names) && a.isObj(valspec.Names[i])) {
+						// Either the new variable is an alias of our object
+						// (whose uses are analyzed too),
+						// or its type is inferred from our object's.
 						continue
 					}
 					tv, ok := a.pkg.TypesInfo.Types[valspec.Type]
@@ -916,9 +1680,9 @@ func (a *analyzer) decl(decl ast.Decl) bool {
 					}
 					intf := getType[*types.Interface](tv.Type)
 					if intf == nil {
-						return false
+						return a.reject(val, ReasonConcreteAssign, "")
 					}
-					a.addMethods(intf)
+					a.addMethods(intf, val, "declaration as "+a.typeString(tv.Type))
 					continue
 				}
 				if !a.expr(val) {
@@ -967,6 +1731,159 @@ func (a *analyzer) switchStmt(stmt *ast.SwitchStmt) bool {
 	return a.stmt(stmt.Body)
 }
 
+// findAliases finds the local variables in body
+// that our object is copied into,
+// by assignment or declaration,
+// directly or via another alias.
+// It records them in a.aliases
+// and returns the identifiers where they are declared.
+//
+// A variable is an alias only if it is declared in body,
+// has the same type as our object,
+// and gets the same new type as our object when that changes (see canAlias).
+// A variable that does not,
+// like g in g := os.Stdin; g = f,
+// is not an alias,
+// and copying our object into it is a concrete assignment.
+// Any other value assigned to an alias has our object's old type,
+// which implements the new interface type,
+// so changing the alias's type along with our object's is safe
+// as long as the alias's uses are all compatible with the new type,
+// which is checked by analyzing them along with our object's.
+// (With StyleGeneric the new type is a type parameter,
+// which such values are not assignable to,
+// so genericOK rules the type parameter out.)
+func (a *analyzer) findAliases(body ast.Node) []*ast.Ident {
+	a.aliases = set.New[types.Object]()
+
+	var (
+		defs  = make(map[types.Object]*ast.Ident)
+		specs = make(map[types.Object]*ast.ValueSpec)
+	)
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			if obj := a.pkg.TypesInfo.Defs[n]; obj != nil {
+				defs[obj] = n
+			}
+		case *ast.ValueSpec:
+			for _, name := range n.Names {
+				if obj := a.pkg.TypesInfo.Defs[name]; obj != nil {
+					specs[obj] = n
+				}
+			}
+		}
+		return true
+	})
+
+	var result []*ast.Ident
+
+	add := func(lhs ast.Expr) bool {
+		id, ok := lhs.(*ast.Ident)
+		if !ok {
+			return false
+		}
+		v, ok := a.pkg.TypesInfo.ObjectOf(id).(*types.Var)
+		if !ok || v == a.obj || a.aliases.Has(v) {
+			return false
+		}
+		def, ok := defs[v]
+		if !ok {
+			// Not declared in body.
+			return false
+		}
+		if !types.Identical(v.Type(), a.obj.Type()) {
+			return false
+		}
+		if !canAlias(def.Pos(), specs[v], id.Pos()) {
+			return false
+		}
+		a.aliases.Add(v)
+		result = append(result, def)
+		return true
+	}
+
+	for changed := true; changed; {
+		changed = false
+		ast.Inspect(body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				if n.Tok != token.ASSIGN && n.Tok != token.DEFINE {
+					return true
+				}
+				if len(n.Lhs) == 2 && len(n.Rhs) == 1 {
+					// A comma-ok form, as in v, ok := <-ch.
+					if a.isObj(n.Rhs[0]) && add(n.Lhs[0]) {
+						changed = true
+					}
+					return true
+				}
+				if len(n.Lhs) != len(n.Rhs) {
+					return true
+				}
+				for i, rhs := range n.Rhs {
+					if a.isObj(rhs) && add(n.Lhs[i]) {
+						changed = true
+					}
+				}
+
+			case *ast.RangeStmt:
+				// The iteration variable of a range over our container.
+				if a.elemsOf == nil || n.Tok != token.DEFINE {
+					return true
+				}
+				if id, ok := ast.Unparen(n.X).(*ast.Ident); !ok || a.pkg.TypesInfo.Uses[id] != a.elemsOf {
+					return true
+				}
+				elem := n.Value
+				if _, ok := types.Unalias(a.elemsOf.Type()).(*types.Chan); ok {
+					elem = n.Key
+				}
+				if elem != nil && add(elem) {
+					changed = true
+				}
+
+			case *ast.ValueSpec:
+				if len(n.Names) != len(n.Values) {
+					return true
+				}
+				for i, val := range n.Values {
+					if a.isObj(val) && add(n.Names[i]) {
+						changed = true
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Pos() < result[j].Pos() })
+	return result
+}
+
+// canAlias tells whether a local variable,
+// declared at pos def
+// (in spec, if it is declared with var),
+// gets the same new type as a parameter
+// that is copied into it at pos.
+// It does if pos is where it is declared,
+// as in g := f or var g = f,
+// so that its type comes from the parameter's,
+// or if it is the only variable declared with an explicit type,
+// as in var g *os.File,
+// which Fix rewrites.
+func canAlias(def token.Pos, spec *ast.ValueSpec, pos token.Pos) bool {
+	if spec != nil && spec.Type != nil {
+		return len(spec.Names) == 1
+	}
+	return def == pos
+}
+
+func isBlank(expr ast.Expr) bool {
+	id := getIdent(expr)
+	return id != nil && id.Name == "_"
+}
+
 func getIdent(expr ast.Expr) *ast.Ident {
 	switch expr := expr.(type) {
 	case *ast.Ident:
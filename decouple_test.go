@@ -15,10 +15,16 @@ import (
 )
 
 func TestCheck(t *testing.T) {
+	t.Run("syntax", func(t *testing.T) { testCheck(t, EngineSyntax) })
+	t.Run("ssa", func(t *testing.T) { testCheck(t, EngineSSA) })
+}
+
+func testCheck(t *testing.T, engine Engine) {
 	checker, err := NewCheckerFromDir("_testdata")
 	if err != nil {
 		t.Fatal(err)
 	}
+	checker.Engine = engine
 
 	// if testing.Verbose() {
 	// 	checker.Verbose = true
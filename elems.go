@@ -0,0 +1,272 @@
+package decouple
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+
+	"github.com/bobg/errors"
+	"golang.org/x/tools/go/packages"
+)
+
+// elemType returns the element type of typ
+// if it is a slice, array, map, or channel type literal,
+// otherwise nil.
+// (The methods of a defined type like "type Files []*os.File"
+// may depend on its element type,
+// so those are excluded.)
+func elemType(typ types.Type) types.Type {
+	switch typ := types.Unalias(typ).(type) {
+	case *types.Slice:
+		return typ.Elem()
+	case *types.Array:
+		return typ.Elem()
+	case *types.Map:
+		return typ.Elem()
+	case *types.Chan:
+		return typ.Elem()
+	}
+	return nil
+}
+
+// checkElems checks whether the elements of the parameter obj,
+// whose type is a slice, array, map, or channel
+// (including a variadic parameter),
+// could have an interface type,
+// as in []io.Reader instead of []*os.File.
+//
+// The parameter itself may only be ranged over,
+// indexed (but not assigned through),
+// received from,
+// compared with nil,
+// or passed to len, cap, close, delete, or clear.
+// Its elements are analyzed like a parameter,
+// with the variables they are copied into
+// (including the iteration variables of range statements)
+// as aliases.
+// This always uses EngineSyntax.
+func (ch Checker) checkElems(pkg *packages.Package, fndecl *ast.FuncDecl, name *ast.Ident, obj types.Object) (ParamResult, error) {
+	elem := elemType(obj.Type())
+	if elem == nil || !hasElemSyntax(fndecl, name) {
+		return ParamResult{Reason: &Reason{Kind: ReasonUnsupported, Pos: pkg.Fset.Position(name.Pos()), Detail: "no element type"}}, nil
+	}
+	if _, ok := elem.(*types.TypeParam); ok {
+		return ParamResult{Reason: &Reason{Kind: ReasonUnsupported, Pos: pkg.Fset.Position(name.Pos()), Detail: "type parameter"}}, nil
+	}
+	if pos, ok := onlyElemsUsed(pkg.TypesInfo, fndecl.Body, obj); !ok {
+		return ParamResult{Reason: &Reason{Kind: ReasonUnsupported, Pos: pkg.Fset.Position(pos), Detail: "use of container"}}, nil
+	}
+
+	// The analyzer's object stands for every element.
+	// It appears nowhere in the syntax;
+	// the elements are found with isElemExpr and as aliases.
+	elemObj := types.NewVar(name.Pos(), pkg.Types, name.Name, elem)
+
+	res, err := ch.checkParamSyntax(pkg, fndecl, name, elemObj, obj)
+	if err != nil {
+		return ParamResult{}, errors.Wrap(err, "analyzing elements")
+	}
+	if res.Reason == nil {
+		res.Elems = true
+	}
+	return res, nil
+}
+
+// hasElemSyntax tells whether the type of parameter name in fndecl
+// is written as a slice, array, map, or channel type
+// (or with "..."),
+// whose element type can be rewritten,
+// rather than as the name of one.
+func hasElemSyntax(fndecl *ast.FuncDecl, name *ast.Ident) bool {
+	for _, field := range fndecl.Type.Params.List {
+		if !slices.Contains(field.Names, name) {
+			continue
+		}
+		switch ast.Unparen(field.Type).(type) {
+		case *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.Ellipsis:
+			return true
+		}
+	}
+	return false
+}
+
+// onlyElemsUsed tells whether body uses the container obj
+// only in the ways permitted by checkElems.
+// If not, it also returns the position of the first other use.
+func onlyElemsUsed(info *types.Info, body ast.Node, obj types.Object) (token.Pos, bool) {
+	var (
+		stack []ast.Node
+		bad   token.Pos
+	)
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		if bad.IsValid() {
+			return false
+		}
+		if id, ok := n.(*ast.Ident); ok && info.Uses[id] == obj {
+			if !elemsUse(info, id, stack) {
+				bad = id.Pos()
+			}
+			return false
+		}
+		stack = append(stack, n)
+		return true
+	})
+	return bad, !bad.IsValid()
+}
+
+// elemsUse tells whether id,
+// an identifier referring to a container parameter,
+// is used in one of the ways permitted by checkElems.
+// The stack holds the nodes enclosing id,
+// innermost last.
+func elemsUse(info *types.Info, id ast.Expr, stack []ast.Node) bool {
+	if len(stack) == 0 {
+		return false
+	}
+	switch parent := stack[len(stack)-1].(type) {
+	case *ast.RangeStmt:
+		if parent.X != id {
+			return false
+		}
+		if parent.Tok == token.DEFINE {
+			// The element variable is new, and will be an alias.
+			return true
+		}
+		elem := parent.Value
+		if _, ok := types.Unalias(info.TypeOf(id)).(*types.Chan); ok {
+			elem = parent.Key
+		}
+		return elem == nil || isBlank(elem)
+
+	case *ast.IndexExpr:
+		if parent.X != id {
+			return false
+		}
+		if len(stack) < 2 {
+			return true
+		}
+		// Storing into the container is not allowed.
+		switch outer := stack[len(stack)-2].(type) {
+		case *ast.AssignStmt:
+			return !slices.Contains(outer.Lhs, ast.Expr(parent))
+		case *ast.IncDecStmt:
+			return false
+		case *ast.RangeStmt:
+			return outer.Key != parent && outer.Value != parent
+		}
+		return true
+
+	case *ast.UnaryExpr:
+		return parent.Op == token.ARROW
+
+	case *ast.CallExpr:
+		if parent.Fun == id {
+			return false
+		}
+		fun, ok := ast.Unparen(parent.Fun).(*ast.Ident)
+		if !ok {
+			return false
+		}
+		if _, ok := info.Uses[fun].(*types.Builtin); !ok {
+			return false
+		}
+		switch fun.Name {
+		case "len", "cap", "close", "delete", "clear":
+			return true
+		}
+		return false
+
+	case *ast.BinaryExpr:
+		if parent.Op != token.EQL && parent.Op != token.NEQ {
+			return false
+		}
+		other := parent.X
+		if other == id {
+			other = parent.Y
+		}
+		return info.Types[other].IsNil()
+	}
+
+	return false
+}
+
+// isElemExpr tells whether expr denotes an element of the container obj:
+// an index expression obj[...] or a receive expression <-obj.
+func isElemExpr(info *types.Info, expr ast.Expr, obj types.Object) bool {
+	isContainer := func(expr ast.Expr) bool {
+		id, ok := ast.Unparen(expr).(*ast.Ident)
+		return ok && info.Uses[id] == obj
+	}
+
+	switch expr := ast.Unparen(expr).(type) {
+	case *ast.IndexExpr:
+		return isContainer(expr.X)
+	case *ast.UnaryExpr:
+		return expr.Op == token.ARROW && isContainer(expr.X)
+	}
+	return false
+}
+
+// WithElem produces the text of the type of parameter param of t.F
+// with its element type replaced by elem,
+// e.g. "[]io.Reader" for "inps []*os.File" and the elem "io.Reader",
+// or "...io.Reader" for a variadic parameter.
+// It is meant for parameters whose ParamResult has Elems set.
+func (t Tuple) WithElem(param, elem string) (string, error) {
+	for _, field := range t.F.Type.Params.List {
+		for _, name := range field.Names {
+			if name.Name == param {
+				return withElem(t.P.Fset, field.Type, elem)
+			}
+		}
+	}
+	return "", fmt.Errorf("no parameter %s in %s", param, t.F.Name.Name)
+}
+
+func withElem(fset *token.FileSet, typ ast.Expr, elem string) (string, error) {
+	switch typ := typ.(type) {
+	case *ast.ParenExpr:
+		return withElem(fset, typ.X, elem)
+
+	case *ast.Ellipsis:
+		return "..." + elem, nil
+
+	case *ast.ArrayType:
+		if typ.Len == nil {
+			return "[]" + elem, nil
+		}
+		n, err := nodeText(fset, typ.Len)
+		if err != nil {
+			return "", errors.Wrap(err, "formatting array length")
+		}
+		return "[" + n + "]" + elem, nil
+
+	case *ast.MapType:
+		key, err := nodeText(fset, typ.Key)
+		if err != nil {
+			return "", errors.Wrap(err, "formatting map key type")
+		}
+		return "map[" + key + "]" + elem, nil
+
+	case *ast.ChanType:
+		switch typ.Dir {
+		case ast.SEND:
+			return "chan<- " + elem, nil
+		case ast.RECV:
+			return "<-chan " + elem, nil
+		}
+		if strings.HasPrefix(elem, "<-") {
+			elem = "(" + elem + ")"
+		}
+		return "chan " + elem, nil
+	}
+
+	return "", fmt.Errorf("no element type in %s at %s", types.ExprString(typ), fset.Position(typ.Pos()))
+}
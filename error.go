@@ -1,6 +1,10 @@
 package decouple
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/bobg/errors"
+)
 
 type derr struct {
 	error
@@ -9,3 +13,22 @@ type derr struct {
 func errf(format string, args ...any) error {
 	return derr{error: fmt.Errorf(format, args...)}
 }
+
+// recoverDerr is deferred by functions that run the analyzers,
+// which report errors by panicking with a derr (see errf).
+// It turns such a panic into an error in *err
+// and re-panics with anything else.
+func recoverDerr(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if e, ok := r.(error); ok {
+		var d derr
+		if errors.As(e, &d) {
+			*err = d
+			return
+		}
+	}
+	panic(r)
+}
@@ -0,0 +1,233 @@
+package decouple
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/bobg/go-generics/v3/set"
+	"golang.org/x/tools/go/packages"
+)
+
+// FieldTuple is the result of checking the fields of a struct type.
+// See Checker.CheckFields.
+type FieldTuple struct {
+	// T is the declaration of the struct type.
+	T *ast.TypeSpec
+
+	// P is the package in which the type declaration appears.
+	P *packages.Package
+
+	// M is a map from the names of fields eligible for decoupling
+	// to MethodMaps for each such field.
+	M map[string]MethodMap
+
+	// Fields maps the name of every field of T checked
+	// to the detailed result of checking it.
+	// For fields not eligible for decoupling,
+	// this includes the reason.
+	Fields map[string]ParamResult
+}
+
+// Pos computes the filename and offset
+// of the name of the struct type in t.
+func (t FieldTuple) Pos() token.Position {
+	return t.P.Fset.Position(t.T.Name.Pos())
+}
+
+// CheckFields checks the fields of the named struct types
+// declared in the Checker's packages,
+// looking for fields with concrete types that could be interfaces instead,
+// like a field of type *sql.DB used only for its QueryContext and ExecContext methods.
+// The result is a list of FieldTuples,
+// one for each struct type with fields,
+// in the order of their declarations.
+//
+// A field is analyzed like a function parameter,
+// at every place in the Checker's packages that selects it
+// (as in x.db).
+// Uses outside those packages cannot be seen,
+// so an exported field may be used in ways this does not account for.
+// A field passed to a function is eligible
+// only if that function's parameter has already been found eligible
+// (normally by Check).
+// Only fields of pointer, function, and interface types are eligible,
+// since those must be set before their methods can be called.
+// A field whose type has a usable zero value,
+// like a bytes.Buffer,
+// is not,
+// since the zero value of an interface type has no methods to call.
+// Embedded fields and fields whose type is a type parameter are not checked.
+// This always uses EngineSyntax.
+func (ch Checker) CheckFields() (_ []FieldTuple, err error) {
+	defer recoverDerr(&err)
+
+	var (
+		result []FieldTuple
+		names  = make(map[types.Object]*ast.Ident)
+	)
+	for _, pkg := range ch.pkgs {
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				gendecl, ok := decl.(*ast.GenDecl)
+				if !ok || gendecl.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gendecl.Specs {
+					tspec, ok := spec.(*ast.TypeSpec)
+					if !ok || tspec.Assign.IsValid() {
+						continue
+					}
+					st, ok := tspec.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+					t := FieldTuple{T: tspec, P: pkg}
+					for _, field := range st.Fields.List {
+						for _, name := range field.Names {
+							if name.Name == "_" {
+								continue
+							}
+							obj := pkg.TypesInfo.Defs[name]
+							if obj == nil {
+								continue
+							}
+							if _, ok := obj.Type().(*types.TypeParam); ok {
+								continue
+							}
+							names[obj] = name
+						}
+					}
+					result = append(result, t)
+				}
+			}
+		}
+	}
+
+	uses := ch.declsUsing(names)
+
+	var nonempty []FieldTuple
+	for _, t := range result {
+		t.M = make(map[string]MethodMap)
+		t.Fields = make(map[string]ParamResult)
+		for _, field := range t.T.Type.(*ast.StructType).Fields.List {
+			for _, name := range field.Names {
+				obj := t.P.TypesInfo.Defs[name]
+				if names[obj] != name {
+					continue
+				}
+				res := ch.checkUses(obj, uses[obj])
+				t.Fields[name.Name] = res
+				if len(res.Methods) > 0 {
+					t.M[name.Name] = res.Methods
+				}
+			}
+		}
+		if len(t.Fields) > 0 {
+			nonempty = append(nonempty, t)
+		}
+	}
+	return nonempty, nil
+}
+
+// declUse is a top-level declaration that refers to some object,
+// and the package containing it.
+type declUse struct {
+	pkg  *packages.Package
+	decl ast.Decl
+}
+
+// declsUsing finds the top-level declarations in the Checker's packages
+// that refer to each of the objects in the keys of objs.
+// (For the fields of generic types,
+// those should be the fields of the origin types.)
+func (ch Checker) declsUsing(objs map[types.Object]*ast.Ident) map[types.Object][]declUse {
+	result := make(map[types.Object][]declUse)
+	for _, pkg := range ch.pkgs {
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				found := set.New[types.Object]()
+				ast.Inspect(decl, func(n ast.Node) bool {
+					id, ok := n.(*ast.Ident)
+					if !ok {
+						return true
+					}
+					obj := pkg.TypesInfo.Uses[id]
+					if v, ok := obj.(*types.Var); ok {
+						obj = v.Origin()
+					}
+					if _, ok := objs[obj]; ok && !found.Has(obj) {
+						found.Add(obj)
+						result[obj] = append(result[obj], declUse{pkg: pkg, decl: decl})
+					}
+					return true
+				})
+			}
+		}
+	}
+	return result
+}
+
+// checkUses analyzes the uses of obj,
+// a struct field or package-level variable,
+// in the given declarations,
+// combining the methods they need
+// as if they were all in the body of a function with obj as a parameter.
+func (ch Checker) checkUses(obj types.Object, uses []declUse) ParamResult {
+	var (
+		intf       = getType[*types.Interface](obj.Type())
+		objmethods MethodMap
+	)
+	if intf != nil {
+		objmethods = make(MethodMap)
+		addMethodsToMap(intf, objmethods)
+	}
+
+	result := ParamResult{
+		Methods: make(MethodMap),
+		Uses:    make(map[string][]Use),
+	}
+	for _, u := range uses {
+		a := analyzer{
+			obj:        obj,
+			pkg:        u.pkg,
+			objmethods: objmethods,
+			methods:    result.Methods,
+			uses:       result.Uses,
+			summaries:  ch.summaries,
+			debug:      ch.Verbose,
+		}
+		a.debugf("%s at %s", obj.Name(), a.pos(u.decl))
+		if fndecl, ok := u.decl.(*ast.FuncDecl); ok && fndecl.Body != nil {
+			result.Aliases = append(result.Aliases, a.findAliases(fndecl.Body)...)
+		}
+		if !a.decl(u.decl) {
+			return ParamResult{Reason: a.reason}
+		}
+		result.Requires = append(result.Requires, a.requires...)
+	}
+
+	if len(objmethods) > 0 && len(result.Methods) >= len(objmethods) {
+		return ParamResult{Reason: &Reason{Kind: ReasonNoNarrower}}
+	}
+	if len(result.Methods) == 0 {
+		return ParamResult{Reason: &Reason{Kind: ReasonNoMethods}}
+	}
+	if !hasNilZero(obj.Type()) {
+		return ParamResult{Reason: &Reason{Kind: ReasonZeroValue, Detail: types.TypeString(obj.Type(), types.RelativeTo(obj.Pkg()))}}
+	}
+	return result
+}
+
+// hasNilZero tells whether the zero value of typ is nil
+// and so cannot have methods called on it
+// (barring pointer methods that check for a nil receiver).
+// Maps and slices are excluded,
+// since nil ones can still be read.
+func hasNilZero(typ types.Type) bool {
+	switch typ.Underlying().(type) {
+	case *types.Pointer, *types.Signature, *types.Interface:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package decouple
+
+import "testing"
+
+func TestCheckFields(t *testing.T) {
+	checker, err := NewCheckerFromDir("_testdata/fields")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checker.Check(); err != nil {
+		t.Fatal(err)
+	}
+	fields, err := checker.CheckFields()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		typ, field string
+		want       string // see testResult
+	}{
+		{typ: "Store", field: "db", want: "[ExecContext QueryContext]"},
+		{typ: "Store", field: "log", want: "[Write]"},
+		{typ: "Store", field: "out", want: "[Write]"},
+		{typ: "Store", field: "raw", want: "returned as concrete type"},
+		{typ: "Store", field: "tmp", want: "[Close]"},
+		{typ: "Store", field: "name", want: "no methods used"},
+		{typ: "Store", field: "buf", want: "zero value of bytes.Buffer is usable"},
+		{typ: "Box", field: "f", want: "[Close]"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.typ+"."+tc.field, func(t *testing.T) {
+			for _, ft := range fields {
+				if ft.T.Name.Name != tc.typ {
+					continue
+				}
+				res, ok := ft.Fields[tc.field]
+				if !ok {
+					t.Fatalf("field %s not checked", tc.field)
+				}
+				testResult(t, res, tc.want)
+				return
+			}
+			t.Fatalf("type %s not found", tc.typ)
+		})
+	}
+}
@@ -0,0 +1,616 @@
+package decouple
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bobg/errors"
+	"github.com/bobg/go-generics/v3/maps"
+	"golang.org/x/tools/go/packages"
+)
+
+// Fix is a set of changes to a Go source file
+// that decouple some function parameters.
+// It is the result of Checker.Fix.
+type Fix struct {
+	// File is the syntax tree of the file being changed.
+	File *ast.File
+
+	// Edits are the changes to parameter types,
+	// in order of position.
+	// They do not overlap.
+	Edits []Edit
+
+	// Imports maps the paths of packages that the new text refers to,
+	// and that File does not already import,
+	// to the local name to use for each one
+	// (or to the empty string when the package's own name will do).
+	Imports map[string]string
+
+	pkg  *packages.Package
+	refs map[string]bool // paths of the packages the new text refers to
+}
+
+// Edit is a change to Go source text:
+// the text between Pos and End is replaced with NewText.
+type Edit struct {
+	Pos, End token.Pos
+	NewText  string
+}
+
+// Fix computes the source changes needed to decouple the parameters in the given tuples.
+// Each parameter in a tuple's M
+// gets the type named by NameForMethods if there is one,
+// or else an interface literal with the methods in its MethodMap
+// (or, when its ParamResult has Elems set,
+// its elements get that type).
+// Likewise each type parameter in a tuple's T
+// gets a new constraint
+// (any, if its MethodMap is empty),
+// and each parameter in its I gets the iterator type
+// (with the range statements over it changed to suit).
+// The parameters in each of its TypeSets get a new type parameter
+// constrained by the type set
+// (see TypeSetNames),
+// as do the results and local variables listed there.
+// With StyleGeneric,
+// parameters get new type parameters constrained by those types instead,
+// where possible
+// (see TypeParamNames).
+// For a tuple about a function literal
+// (with L set),
+// the literal's parameters change.
+// All the tuples must refer to functions in the same file.
+func (ch Checker) Fix(tuples ...Tuple) (Fix, error) {
+	if len(tuples) == 0 {
+		return Fix{}, nil
+	}
+
+	var (
+		pkg  = tuples[0].P
+		file = fileFor(pkg, tuples[0].F.Pos())
+	)
+	if file == nil {
+		return Fix{}, fmt.Errorf("no file found for %s", tuples[0].Pos())
+	}
+
+	result := Fix{File: file, pkg: pkg}
+
+	for _, t := range tuples {
+		if t.P != pkg || fileFor(t.P, t.F.Pos()) != file {
+			return Fix{}, fmt.Errorf("%s is not in the same file as %s", t.Pos(), tuples[0].Pos())
+		}
+		q := newQualifier(pkg, file, t.F)
+		edits, err := ch.paramEdits(t, q)
+		if err != nil {
+			return Fix{}, errors.Wrapf(err, "fixing %s", t.F.Name.Name)
+		}
+		result.Edits = append(result.Edits, edits...)
+		for path, name := range q.added {
+			if result.Imports == nil {
+				result.Imports = make(map[string]string)
+			}
+			result.Imports[path] = name
+		}
+		for path := range q.refs {
+			if result.refs == nil {
+				result.refs = make(map[string]bool)
+			}
+			result.refs[path] = true
+		}
+	}
+
+	sort.Slice(result.Edits, func(i, j int) bool {
+		return result.Edits[i].Pos < result.Edits[j].Pos
+	})
+
+	return result, nil
+}
+
+func (ch Checker) paramEdits(t Tuple, q *qualifier) ([]Edit, error) {
+	var tpNames map[string]string
+	if ch.Style == StyleGeneric {
+		tpNames = ch.TypeParamNames(t)
+	}
+
+	var (
+		newTypes   = make(map[string]string)
+		aliasTypes = make(map[string]string) // parameter name -> new type of its aliases
+		newTParams = make(map[string]string) // parameter name -> new type-parameter declaration
+	)
+	for param, mm := range t.M {
+		if len(mm) == 0 {
+			continue
+		}
+		text, err := ch.typeText(mm, q)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parameter %s", param)
+		}
+		if tp, ok := tpNames[param]; ok {
+			newTParams[param] = tp + " " + text
+			text = tp
+		}
+		aliasTypes[param] = text
+		if t.Params[param].Elems {
+			// The aliases are elements, and the parameter is their container.
+			if text, err = t.WithElem(param, text); err != nil {
+				return nil, err
+			}
+		}
+		newTypes[param] = text
+	}
+	for param, it := range t.I {
+		newTypes[param] = it.TypeString(q.qualify)
+	}
+
+	var (
+		tsNames = ch.TypeSetNames(t)
+		tsDecls []string
+		result  []Edit
+	)
+	for i, ts := range t.S {
+		tp := tsNames[i]
+		tsDecls = append(tsDecls, tp+" "+ts.Constraint(q.qualify))
+		for _, param := range ts.Params {
+			newTypes[param] = tp
+		}
+		for _, expr := range ts.Vars {
+			result = append(result, Edit{Pos: expr.Pos(), End: expr.End(), NewText: tp})
+		}
+		edits, err := resultEdits(t.FuncType().Results, ts.Results, tp)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, edits...)
+	}
+
+	edits, err := fieldEdits(t.P.Fset, t.FuncType().Params, newTypes)
+	if err != nil {
+		return nil, err
+	}
+	result = append(result, edits...)
+
+	if len(newTParams) > 0 || len(tsDecls) > 0 {
+		var decls []string
+		for _, field := range t.F.Type.Params.List {
+			for _, name := range field.Names {
+				if decl, ok := newTParams[name.Name]; ok {
+					decls = append(decls, decl)
+				}
+			}
+		}
+		decls = append(decls, tsDecls...)
+		if tparams := t.F.Type.TypeParams; tparams != nil {
+			// Add to the existing type parameters.
+			result = append(result, Edit{
+				Pos:     tparams.Closing,
+				End:     tparams.Closing,
+				NewText: ", " + strings.Join(decls, ", "),
+			})
+		} else {
+			result = append(result, Edit{
+				Pos:     t.F.Name.End(),
+				End:     t.F.Name.End(),
+				NewText: "[" + strings.Join(decls, ", ") + "]",
+			})
+		}
+	}
+
+	for _, it := range t.I {
+		result = append(result, it.Edits...)
+	}
+
+	if len(t.T) > 0 {
+		newConstraints := make(map[string]string)
+		for tparam, mm := range t.T {
+			text, err := ch.typeText(mm, q)
+			if err != nil {
+				return nil, errors.Wrapf(err, "type parameter %s", tparam)
+			}
+			newConstraints[tparam] = text
+		}
+		edits, err := fieldEdits(t.P.Fset, t.F.Type.TypeParams, newConstraints)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, edits...)
+	}
+
+	// Aliases declared with an explicit type need the new type too.
+	aliasIdents := make(map[*ast.Ident]string)
+	for param, text := range aliasTypes {
+		for _, alias := range t.Params[param].Aliases {
+			aliasIdents[alias] = text
+		}
+	}
+	if len(aliasIdents) > 0 {
+		ast.Inspect(t.F.Body, func(n ast.Node) bool {
+			spec, ok := n.(*ast.ValueSpec)
+			if !ok || spec.Type == nil || len(spec.Names) != 1 {
+				return true
+			}
+			if text, ok := aliasIdents[spec.Names[0]]; ok {
+				result = append(result, Edit{
+					Pos:     spec.Type.Pos(),
+					End:     spec.Type.End(),
+					NewText: text,
+				})
+			}
+			return true
+		})
+	}
+
+	return result, nil
+}
+
+// fieldEdits changes the types of the names in fields
+// (function parameters or type parameters)
+// according to newTypes,
+// which maps names to the text of their new types.
+func fieldEdits(fset *token.FileSet, fields *ast.FieldList, newTypes map[string]string) ([]Edit, error) {
+	var result []Edit
+	for _, field := range fields.List {
+		var (
+			changed int
+			texts   = make(map[string]bool)
+		)
+		for _, name := range field.Names {
+			if text, ok := newTypes[name.Name]; ok {
+				changed++
+				texts[text] = true
+			}
+		}
+		if changed == 0 {
+			continue
+		}
+		if changed == len(field.Names) && len(texts) == 1 {
+			// All the names in this field get the same new type.
+			result = append(result, Edit{
+				Pos:     field.Type.Pos(),
+				End:     field.Type.End(),
+				NewText: newTypes[field.Names[0].Name],
+			})
+			continue
+		}
+
+		// Split the field up, e.g. "a, b *os.File" -> "a io.Reader, b *os.File".
+		oldText, err := nodeText(fset, field.Type)
+		if err != nil {
+			return nil, errors.Wrapf(err, "formatting type of %s", field.Names[0].Name)
+		}
+		var parts []string
+		for _, name := range field.Names {
+			text, ok := newTypes[name.Name]
+			if !ok {
+				text = oldText
+			}
+			parts = append(parts, name.Name+" "+text)
+		}
+		result = append(result, Edit{
+			Pos:     field.Pos(),
+			End:     field.End(),
+			NewText: strings.Join(parts, ", "),
+		})
+	}
+	return result, nil
+}
+
+// resultEdits changes the types of the unnamed results of a function
+// with the given indexes
+// to tp.
+func resultEdits(results *ast.FieldList, indexes []int, tp string) ([]Edit, error) {
+	var result []Edit
+	for _, i := range indexes {
+		if results == nil || i >= len(results.List) || len(results.List[i].Names) > 0 {
+			return nil, fmt.Errorf("no unnamed result %d", i)
+		}
+		typ := results.List[i].Type
+		result = append(result, Edit{Pos: typ.Pos(), End: typ.End(), NewText: tp})
+	}
+	return result, nil
+}
+
+// typeText produces the text of an interface type with the methods in mm,
+// as it should appear in the file that q is for.
+func (ch Checker) typeText(mm MethodMap, q *qualifier) (string, error) {
+	if len(mm) == 0 {
+		return "any", nil
+	}
+	if _, obj := ch.interfaceForMethods(mm, q.pkg); obj != nil {
+		return types.TypeString(obj.Type(), q.qualify), nil
+	}
+
+	methodNames := maps.Keys(mm)
+	sort.Strings(methodNames)
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("interface{ ")
+	for i, methodName := range methodNames {
+		sig := mm[methodName]
+		if !ast.IsExported(methodName) {
+			if recv := sig.Recv(); recv != nil && recv.Pkg() != nil && recv.Pkg() != q.pkg {
+				return "", fmt.Errorf("cannot refer to unexported method %s of package %s", methodName, recv.Pkg().Path())
+			}
+		}
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(methodName)
+		types.WriteSignature(buf, sig, q.qualify)
+	}
+	buf.WriteString(" }")
+
+	return buf.String(), nil
+}
+
+// ImportEdits returns the changes to the import declarations of f.File
+// that go along with f.Edits:
+// it adds the packages in f.Imports,
+// and removes imports whose only remaining uses are in text replaced by f.Edits
+// (unless the new text refers to them too).
+func (f Fix) ImportEdits() []Edit {
+	if f.File == nil {
+		return nil
+	}
+
+	var result []Edit
+
+	// Count the uses of each imported package name outside the edited regions.
+	var (
+		info  = f.pkg.TypesInfo
+		inUse = make(map[*types.PkgName]bool)
+	)
+	for id, obj := range info.Uses {
+		pkgName, ok := obj.(*types.PkgName)
+		if !ok || id.Pos() < f.File.FileStart || id.Pos() >= f.File.FileEnd {
+			continue
+		}
+		if f.edited(id.Pos()) {
+			continue
+		}
+		inUse[pkgName] = true
+	}
+
+	for _, decl := range f.File.Decls {
+		gendecl, ok := decl.(*ast.GenDecl)
+		if !ok || gendecl.Tok != token.IMPORT {
+			continue
+		}
+		var removed int
+		for _, spec := range gendecl.Specs {
+			imp := spec.(*ast.ImportSpec)
+			if imp.Name != nil && (imp.Name.Name == "_" || imp.Name.Name == ".") {
+				continue
+			}
+			pkgName := info.PkgNameOf(imp)
+			if pkgName == nil || inUse[pkgName] || f.refs[pkgName.Imported().Path()] || !f.usedInEdits(pkgName) {
+				continue
+			}
+			removed++
+			if gendecl.Lparen.IsValid() {
+				result = append(result, f.wholeLines(imp.Pos(), imp.End()))
+			}
+		}
+		if removed > 0 && removed == len(gendecl.Specs) {
+			// Remove the whole declaration instead.
+			if gendecl.Lparen.IsValid() {
+				result = result[:len(result)-removed]
+			}
+			result = append(result, f.wholeLines(gendecl.Pos(), gendecl.End()))
+		}
+	}
+
+	if len(f.Imports) > 0 {
+		paths := maps.Keys(f.Imports)
+		sort.Strings(paths)
+
+		var specs []string
+		for _, path := range paths {
+			spec := strconv.Quote(path)
+			if name := f.Imports[path]; name != "" {
+				spec = name + " " + spec
+			}
+			specs = append(specs, spec)
+		}
+
+		var (
+			pos    = f.File.Name.End()
+			lparen bool
+		)
+		for _, decl := range f.File.Decls {
+			if gendecl, ok := decl.(*ast.GenDecl); ok && gendecl.Tok == token.IMPORT {
+				pos, lparen = gendecl.End(), gendecl.Lparen.IsValid()
+				if lparen {
+					pos = gendecl.Rparen
+				}
+			}
+		}
+
+		for i, e := range result {
+			if e.Pos <= pos && pos < e.End {
+				// The declaration we were going to add to is being removed.
+				// Replace it instead.
+				var text string
+				for _, spec := range specs {
+					text += "import " + spec + "\n"
+				}
+				result[i].NewText = text
+				return result
+			}
+		}
+
+		var text string
+		switch {
+		case lparen:
+			// Add to the existing parenthesized import list.
+			for _, spec := range specs {
+				text += "\t" + spec + "\n"
+			}
+		case pos == f.File.Name.End():
+			text = "\n"
+			fallthrough
+		default:
+			for _, spec := range specs {
+				text += "\nimport " + spec
+			}
+		}
+		result = append(result, Edit{Pos: pos, End: pos, NewText: text})
+	}
+
+	return result
+}
+
+func (f Fix) edited(pos token.Pos) bool {
+	for _, e := range f.Edits {
+		if e.Pos <= pos && pos < e.End {
+			return true
+		}
+	}
+	return false
+}
+
+// usedInEdits tells whether the given object (an imported package name)
+// is referred to in the text replaced by f.Edits,
+// i.e., whether the edits are responsible for it becoming unused.
+func (f Fix) usedInEdits(obj types.Object) bool {
+	for id, use := range f.pkg.TypesInfo.Uses {
+		if use == obj && f.edited(id.Pos()) {
+			return true
+		}
+	}
+	return false
+}
+
+// wholeLines returns an Edit that deletes the text from pos to end,
+// extended to include the rest of the line that end is on.
+func (f Fix) wholeLines(pos, end token.Pos) Edit {
+	tokFile := f.pkg.Fset.File(pos)
+	line := tokFile.Line(end)
+	if line < tokFile.LineCount() {
+		end = tokFile.LineStart(line + 1)
+	}
+	return Edit{Pos: pos, End: end}
+}
+
+func fileFor(pkg *packages.Package, pos token.Pos) *ast.File {
+	for _, file := range pkg.Syntax {
+		if file.FileStart <= pos && pos < file.FileEnd {
+			return file
+		}
+	}
+	return nil
+}
+
+func nodeText(fset *token.FileSet, node ast.Node) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := format.Node(buf, fset, node); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// qualifier is a types.Qualifier for referring to types from within a given file,
+// keeping track of which new imports that requires.
+type qualifier struct {
+	pkg   *types.Package
+	names map[string]string // import path -> local name
+	taken map[string]bool   // local names already in use
+	added map[string]string // new imports: path -> alias, or "" for none
+	refs  map[string]bool   // paths of all the packages qualified so far
+}
+
+func newQualifier(pkg *packages.Package, file *ast.File, fndecl *ast.FuncDecl) *qualifier {
+	q := &qualifier{
+		pkg:   pkg.Types,
+		names: make(map[string]string),
+		taken: make(map[string]bool),
+		added: make(map[string]string),
+		refs:  make(map[string]bool),
+	}
+	for _, imp := range file.Imports {
+		if imp.Name != nil && (imp.Name.Name == "_" || imp.Name.Name == ".") {
+			continue
+		}
+		pkgName := pkg.TypesInfo.PkgNameOf(imp)
+		if pkgName == nil {
+			continue
+		}
+		q.names[pkgName.Imported().Path()] = pkgName.Name()
+		q.taken[pkgName.Name()] = true
+	}
+	for _, name := range pkg.Types.Scope().Names() {
+		q.taken[name] = true
+	}
+	for _, fields := range []*ast.FieldList{fndecl.Recv, fndecl.Type.TypeParams, fndecl.Type.Params, fndecl.Type.Results} {
+		if fields == nil {
+			continue
+		}
+		for _, field := range fields.List {
+			for _, name := range field.Names {
+				q.taken[name.Name] = true
+			}
+		}
+	}
+	return q
+}
+
+func (q *qualifier) qualify(p *types.Package) string {
+	if p == q.pkg {
+		return ""
+	}
+	q.refs[p.Path()] = true
+	if name, ok := q.names[p.Path()]; ok {
+		return name
+	}
+	name := p.Name()
+	for i := 2; q.taken[name]; i++ {
+		name = fmt.Sprintf("%s%d", p.Name(), i)
+	}
+	q.names[p.Path()] = name
+	q.taken[name] = true
+	if name == p.Name() {
+		q.added[p.Path()] = ""
+	} else {
+		q.added[p.Path()] = name
+	}
+	return name
+}
+
+// Apply applies f.Edits and f.ImportEdits to src,
+// which must be the content of f.File,
+// and returns the result formatted with gofmt.
+func (f Fix) Apply(src []byte) ([]byte, error) {
+	edits := append(append([]Edit{}, f.Edits...), f.ImportEdits()...)
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].Pos < edits[j].Pos
+	})
+
+	var (
+		tokFile = f.pkg.Fset.File(f.File.Pos())
+		buf     = new(bytes.Buffer)
+		last    int
+	)
+	for _, e := range edits {
+		start, end := tokFile.Offset(e.Pos), tokFile.Offset(e.End)
+		if start < last {
+			return nil, fmt.Errorf("overlapping edits at %s", tokFile.Position(e.Pos))
+		}
+		if end > len(src) {
+			return nil, fmt.Errorf("edit at %s is past the end of the file", tokFile.Position(e.Pos))
+		}
+		buf.Write(src[last:start])
+		buf.WriteString(e.NewText)
+		last = end
+	}
+	buf.Write(src[last:])
+
+	result, err := format.Source(buf.Bytes())
+	return result, errors.Wrapf(err, "formatting %s", tokFile.Name())
+}
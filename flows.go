@@ -0,0 +1,311 @@
+package decouple
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// flows calls f for each place in the syntax of pkg
+// where the value of an expression flows into a destination of a known type:
+// the variable in an assignment or declaration,
+// the parameter in a function call,
+// the result in a return statement,
+// the element type in a send statement or composite literal,
+// or the target type of a conversion.
+// The destination type may differ from the expression's type,
+// as when a concrete value is assigned to an interface variable.
+func flows(pkg *packages.Package, f func(dst types.Type, src ast.Expr)) {
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Body == nil {
+					continue
+				}
+				sig, _ := pkg.TypesInfo.TypeOf(decl.Name).(*types.Signature)
+				flowsIn(pkg, decl.Body, sig, f)
+
+			case *ast.GenDecl:
+				flowsIn(pkg, decl, nil, f)
+			}
+		}
+	}
+}
+
+// flowsIn is like flows for a single syntax tree
+// in a function with the given signature
+// (nil outside of any function).
+func flowsIn(pkg *packages.Package, node ast.Node, sig *types.Signature, f func(dst types.Type, src ast.Expr)) {
+	info := pkg.TypesInfo
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			litsig, _ := info.TypeOf(n).(*types.Signature)
+			flowsIn(pkg, n.Body, litsig, f)
+			return false
+
+		case *ast.AssignStmt:
+			if (n.Tok != token.ASSIGN && n.Tok != token.DEFINE) || len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, rhs := range n.Rhs {
+				if isBlank(n.Lhs[i]) {
+					continue
+				}
+				if dst := info.TypeOf(n.Lhs[i]); dst != nil {
+					f(dst, rhs)
+				}
+			}
+
+		case *ast.ValueSpec:
+			if n.Type == nil {
+				return true
+			}
+			dst := info.TypeOf(n.Type)
+			for _, val := range n.Values {
+				f(dst, val)
+			}
+
+		case *ast.CallExpr:
+			tv, ok := info.Types[n.Fun]
+			if !ok {
+				return true
+			}
+			if tv.IsType() {
+				if len(n.Args) == 1 {
+					f(tv.Type, n.Args[0])
+				}
+				return true
+			}
+			callsig := getType[*types.Signature](tv.Type)
+			if callsig == nil {
+				return true
+			}
+			params := callsig.Params()
+			for i, arg := range n.Args {
+				var dst types.Type
+				switch {
+				case callsig.Variadic() && i >= params.Len()-1:
+					dst = params.At(params.Len() - 1).Type()
+					if n.Ellipsis == token.NoPos {
+						if slice, ok := dst.(*types.Slice); ok {
+							dst = slice.Elem()
+						}
+					}
+				case i < params.Len():
+					dst = params.At(i).Type()
+				}
+				if dst != nil {
+					f(dst, arg)
+				}
+			}
+
+		case *ast.ReturnStmt:
+			if sig == nil || len(n.Results) != sig.Results().Len() {
+				return true
+			}
+			for i, res := range n.Results {
+				f(sig.Results().At(i).Type(), res)
+			}
+
+		case *ast.SendStmt:
+			if ch := getType[*types.Chan](info.TypeOf(n.Chan)); ch != nil {
+				f(ch.Elem(), n.Value)
+			}
+
+		case *ast.CompositeLit:
+			compositeFlows(info.TypeOf(n), n, f)
+		}
+		return true
+	})
+}
+
+func compositeFlows(typ types.Type, lit *ast.CompositeLit, f func(dst types.Type, src ast.Expr)) {
+	if ptr := getType[*types.Pointer](typ); ptr != nil {
+		// This is an elided &T{...} in an outer composite literal.
+		typ = ptr.Elem()
+	}
+	if typ == nil {
+		return
+	}
+
+	switch u := typ.Underlying().(type) {
+	case *types.Struct:
+		for i, elt := range lit.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				id, ok := kv.Key.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				for j := 0; j < u.NumFields(); j++ {
+					if field := u.Field(j); field.Name() == id.Name {
+						f(field.Type(), kv.Value)
+						break
+					}
+				}
+				continue
+			}
+			if i < u.NumFields() {
+				f(u.Field(i).Type(), elt)
+			}
+		}
+
+	case *types.Slice:
+		elemFlows(u.Elem(), nil, lit, f)
+
+	case *types.Array:
+		elemFlows(u.Elem(), nil, lit, f)
+
+	case *types.Map:
+		elemFlows(u.Elem(), u.Key(), lit, f)
+	}
+}
+
+// elemFlows reports the flows into the elements
+// (and, for maps, keys)
+// of a composite literal.
+func elemFlows(elem, key types.Type, lit *ast.CompositeLit, f func(dst types.Type, src ast.Expr)) {
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if key != nil {
+				f(key, kv.Key)
+			}
+			elt = kv.Value
+		}
+		if inner, ok := elt.(*ast.CompositeLit); ok && inner.Type == nil {
+			// The type of an elided inner literal is the element type,
+			// and its own flows are found when ast.Inspect reaches it.
+			continue
+		}
+		f(elem, elt)
+	}
+}
+
+// ifaceUse is a place where a value of concrete type is used as an interface.
+type ifaceUse struct {
+	typ, intf types.Type
+	pos       token.Position
+}
+
+func findIfaceUses(pkg *packages.Package) []ifaceUse {
+	var result []ifaceUse
+	flows(pkg, func(dst types.Type, src ast.Expr) {
+		if getType[*types.Interface](dst) == nil {
+			return
+		}
+		typ := pkg.TypesInfo.TypeOf(src)
+		if typ == nil || types.IsInterface(typ) {
+			return
+		}
+		result = append(result, ifaceUse{typ: typ, intf: dst, pos: pkg.Fset.Position(src.Pos())})
+	})
+	return result
+}
+
+// constrainingInterface looks for a place where a type with the method fn
+// is used as an interface that includes fn.
+// Changing the signature of fn would break such a use.
+func (ch Checker) constrainingInterface(fn *types.Func) (ifaceUse, bool) {
+	for _, use := range ch.ifaceUses {
+		intf := getType[*types.Interface](use.intf)
+		if intf == nil {
+			continue
+		}
+		var found bool
+		for i := 0; i < intf.NumMethods(); i++ {
+			if intf.Method(i).Name() == fn.Name() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+		obj, _, _ := types.LookupFieldOrMethod(use.typ, true, fn.Pkg(), fn.Name())
+		if m, ok := obj.(*types.Func); ok && m.Origin() == fn {
+			return use, true
+		}
+	}
+	return ifaceUse{}, false
+}
+
+// funcValueUse is a place where a function is used as a value
+// rather than called.
+type funcValueUse struct {
+	typ types.Type // the type the function value is used as
+	pos token.Position
+}
+
+// findFuncValues adds to m the functions referred to in pkg
+// other than by calling them,
+// such as callbacks passed to other functions
+// and method values.
+func findFuncValues(pkg *packages.Package, m map[*types.Func]funcValueUse) {
+	info := pkg.TypesInfo
+
+	add := func(fn *types.Func, typ types.Type, pos token.Pos) {
+		if _, ok := m[fn]; !ok {
+			m[fn] = funcValueUse{typ: typ, pos: pkg.Fset.Position(pos)}
+		}
+	}
+
+	// First, the uses with a known destination type.
+	flows(pkg, func(dst types.Type, src ast.Expr) {
+		if _, fn := funcRef(info, src); fn != nil {
+			add(fn, dst, src.Pos())
+		}
+	})
+
+	// Then any others.
+	called := make(map[*ast.Ident]bool)
+	for _, file := range pkg.Syntax {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				if id, _ := funcRef(info, call.Fun); id != nil {
+					called[id] = true
+				}
+			}
+			return true
+		})
+	}
+	for _, file := range pkg.Syntax {
+		ast.Inspect(file, func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			if !ok || called[id] {
+				return true
+			}
+			if fn, ok := info.Uses[id].(*types.Func); ok {
+				add(fn.Origin(), info.TypeOf(id), id.Pos())
+			}
+			return true
+		})
+	}
+}
+
+// funcRef returns the function that expr refers to, if any,
+// and the identifier naming it.
+func funcRef(info *types.Info, expr ast.Expr) (*ast.Ident, *types.Func) {
+	var id *ast.Ident
+	switch expr := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		id = expr
+	case *ast.SelectorExpr:
+		id = expr.Sel
+	case *ast.IndexExpr:
+		// An explicitly instantiated generic function.
+		return funcRef(info, expr.X)
+	case *ast.IndexListExpr:
+		return funcRef(info, expr.X)
+	default:
+		return nil, nil
+	}
+	fn, _ := info.Uses[id].(*types.Func)
+	if fn == nil {
+		return nil, nil
+	}
+	return id, fn.Origin()
+}
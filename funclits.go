@@ -0,0 +1,144 @@
+package decouple
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"github.com/bobg/go-generics/v3/set"
+	"golang.org/x/tools/go/packages"
+)
+
+// CheckFuncLits checks the parameters of the function literals in fndecl,
+// which should appear in the given package,
+// which should be one of the packages contained in the Checker.
+// The result is a list of Tuples,
+// one for each literal checked,
+// with L set to the literal and F to fndecl.
+//
+// Only literals whose types are not constrained by where they are used
+// can have their parameters' types changed,
+// so only these are checked:
+// literals that are called directly
+// (as in "go func(f *os.File) { ... }(f)"),
+// and literals that initialize a local variable whose type is inferred
+// (as in "handle := func(f *os.File) { ... }")
+// when that variable is used only to call the literal.
+// This always uses EngineSyntax.
+func (ch Checker) CheckFuncLits(pkg *packages.Package, fndecl *ast.FuncDecl) (_ []Tuple, err error) {
+	defer recoverDerr(&err)
+
+	if fndecl.Body == nil {
+		return nil, nil
+	}
+
+	var result []Tuple
+	for _, lit := range unconstrainedLits(pkg.TypesInfo, fndecl.Body) {
+		params := make(map[string]ParamResult)
+		for _, field := range lit.Type.Params.List {
+			for _, name := range field.Names {
+				if name.Name == "_" {
+					continue
+				}
+				obj, ok := pkg.TypesInfo.Defs[name]
+				if !ok {
+					return nil, fmt.Errorf("no def found for %s at %s", name.Name, pkg.Fset.Position(name.Pos()))
+				}
+				if _, ok := obj.Type().(*types.TypeParam); ok {
+					// The literal is in a generic function and cannot have type parameters of its own.
+					params[name.Name] = ParamResult{Reason: &Reason{Kind: ReasonUnsupported, Pos: pkg.Fset.Position(name.Pos()), Detail: "type parameter"}}
+					continue
+				}
+				params[name.Name] = ch.checkParamBody(pkg, &funcDeclOrLit{lit: lit}, lit.Body, name, obj, nil)
+			}
+		}
+		if len(params) == 0 {
+			continue
+		}
+		result = append(result, Tuple{
+			F:      fndecl,
+			L:      lit,
+			P:      pkg,
+			M:      methodMaps(params),
+			Params: params,
+		})
+	}
+	return result, nil
+}
+
+// unconstrainedLits finds the function literals in body
+// whose types are not constrained by where they are used
+// (see CheckFuncLits),
+// in the order they appear.
+func unconstrainedLits(info *types.Info, body ast.Node) []*ast.FuncLit {
+	var (
+		result []*ast.FuncLit
+		vars   = make(map[types.Object]*ast.FuncLit) // local variables initialized with literals
+		called = set.New[*ast.Ident]()                // identifiers used as the function in a call
+	)
+
+	addVar := func(lhs ast.Expr, rhs ast.Expr) {
+		lit, ok := ast.Unparen(rhs).(*ast.FuncLit)
+		if !ok {
+			return
+		}
+		id, ok := lhs.(*ast.Ident)
+		if !ok {
+			return
+		}
+		if obj := info.Defs[id]; obj != nil {
+			vars[obj] = lit
+		}
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			switch fun := ast.Unparen(n.Fun).(type) {
+			case *ast.FuncLit:
+				result = append(result, fun)
+			case *ast.Ident:
+				called.Add(fun)
+			}
+
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE && len(n.Lhs) == len(n.Rhs) {
+				for i, rhs := range n.Rhs {
+					addVar(n.Lhs[i], rhs)
+				}
+			}
+
+		case *ast.ValueSpec:
+			if n.Type == nil && len(n.Names) == len(n.Values) {
+				for i, val := range n.Values {
+					addVar(n.Names[i], val)
+				}
+			}
+		}
+		return true
+	})
+
+	// Any use of a variable other than calling it
+	// (passing it, assigning to it, etc.)
+	// constrains its type.
+	ast.Inspect(body, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		if obj := info.Uses[id]; obj != nil && !called.Has(id) {
+			delete(vars, obj)
+		}
+		return true
+	})
+
+	for _, lit := range vars {
+		result = append(result, lit)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Pos() < result[j].Pos()
+	})
+	return result
+}
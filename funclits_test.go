@@ -0,0 +1,71 @@
+package decouple
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/bobg/go-generics/v3/maps"
+)
+
+func TestCheckFuncLits(t *testing.T) {
+	checker, err := NewCheckerFromDir("_testdata/funclits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker.FuncLits = true
+
+	tuples, err := checker.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string][]string) // enclosing function -> "param: methods" for each literal param
+	for _, tuple := range tuples {
+		if tuple.L == nil {
+			continue
+		}
+		params := maps.Keys(tuple.M)
+		sort.Strings(params)
+		for _, param := range params {
+			methods := maps.Keys(tuple.M[param])
+			sort.Strings(methods)
+			got[tuple.F.Name.Name] = append(got[tuple.F.Name.Name], fmt.Sprintf("%s: %v", param, methods))
+		}
+		if len(params) == 0 {
+			got[tuple.F.Name.Name] = append(got[tuple.F.Name.Name], "")
+		}
+	}
+
+	cases := []struct {
+		fn   string
+		want []string
+	}{
+		{fn: "Direct", want: []string{"f: [Close]"}},
+		{fn: "Local", want: []string{"r: [Read]"}},
+		{fn: "Var", want: []string{"w: [Write]"}},
+		{fn: "Passed"},
+		{fn: "Reassigned"},
+		{fn: "Typed"},
+		{fn: "Concrete", want: []string{""}},
+		{fn: "Generic", want: []string{"c: [Close]"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.fn, func(t *testing.T) {
+			if fmt.Sprint(got[tc.fn]) != fmt.Sprint(tc.want) {
+				t.Errorf("got %q, want %q", got[tc.fn], tc.want)
+			}
+		})
+	}
+
+	checker.FuncLits = false
+	tuples, err = checker.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tuple := range tuples {
+		if tuple.L != nil {
+			t.Errorf("got literal in %s without FuncLits", tuple.F.Name.Name)
+		}
+	}
+}
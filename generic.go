@@ -0,0 +1,197 @@
+package decouple
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"github.com/bobg/go-generics/v3/set"
+	"golang.org/x/tools/go/packages"
+)
+
+// Style selects the form of the changes that Checker.Fix makes.
+type Style int
+
+const (
+	// StyleInterface changes the type of each decoupled parameter
+	// to an interface type,
+	// e.g. func F(r io.Reader).
+	// This is the default.
+	StyleInterface Style = iota
+
+	// StyleGeneric gives each decoupled parameter the type of a new type parameter
+	// constrained by the interface type,
+	// e.g. func F[R io.Reader](r R).
+	// This avoids the cost of dynamic dispatch,
+	// since calls through the parameter are resolved when F is instantiated.
+	// Parameters for which this is not possible
+	// (see ParamResult.Generic)
+	// get the interface type instead.
+	StyleGeneric
+)
+
+// genericOK tells whether a parameter of fndecl
+// (or its elements),
+// whose value is in the expressions for which isObj is true,
+// could have the type of a new type parameter.
+// Methods cannot have type parameters,
+// and values of type-parameter type cannot be used in type assertions and type switches,
+// be compared with the values of interface type that they now could be compared with,
+// or be assigned other values
+// (including in the declaration of an alias).
+func genericOK(fndecl *ast.FuncDecl, isObj func(ast.Expr) bool) bool {
+	if fndecl.Recv != nil {
+		return false
+	}
+
+	ok := true
+	ast.Inspect(fndecl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, lhs := range n.Lhs {
+				if isObj(lhs) && !isObj(n.Rhs[i]) {
+					ok = false
+				}
+			}
+		case *ast.ValueSpec:
+			if len(n.Names) != len(n.Values) {
+				return true
+			}
+			for i, name := range n.Names {
+				if isObj(name) && !isObj(n.Values[i]) {
+					ok = false
+				}
+			}
+		case *ast.TypeAssertExpr:
+			// This includes the x.(type) in a type switch.
+			if isObj(n.X) {
+				ok = false
+			}
+		case *ast.BinaryExpr:
+			if (n.Op == token.EQL || n.Op == token.NEQ) && (isObj(n.X) || isObj(n.Y)) {
+				ok = false
+			}
+		case *ast.SwitchStmt:
+			// The tag is compared with each case.
+			if n.Tag != nil && isObj(n.Tag) {
+				ok = false
+			}
+		case *ast.CaseClause:
+			for _, expr := range n.List {
+				if isObj(expr) {
+					ok = false
+				}
+			}
+		}
+		return ok
+	})
+	return ok
+}
+
+// findUninferable finds the parameters of functions called in pkg
+// whose types could not be inferred at some call
+// if they were type parameters:
+// those given untyped nil or an untyped constant,
+// whose default type (if any) is not the parameter's type,
+// and variadic parameters given no arguments.
+func findUninferable(pkg *packages.Package) []types.Object {
+	var (
+		info   = pkg.TypesInfo
+		result []types.Object
+	)
+	for _, file := range pkg.Syntax {
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			_, fn := funcRef(info, call.Fun)
+			if fn == nil {
+				return true
+			}
+			var (
+				sig     = fn.Type().(*types.Signature)
+				nparams = sig.Params().Len()
+			)
+			if sig.Variadic() && len(call.Args) < nparams {
+				// Unless the arguments are the results of another call, as in f(g()).
+				var multi bool
+				if len(call.Args) == 1 {
+					_, multi = info.TypeOf(call.Args[0]).(*types.Tuple)
+				}
+				if !multi {
+					result = append(result, sig.Params().At(nparams-1))
+				}
+			}
+			for i, arg := range call.Args {
+				basic, ok := info.TypeOf(arg).(*types.Basic)
+				if !ok || basic.Info()&types.IsUntyped == 0 {
+					continue
+				}
+				if i >= nparams {
+					i = nparams - 1 // variadic
+				}
+				result = append(result, sig.Params().At(i))
+			}
+			return true
+		})
+	}
+	return result
+}
+
+// TypeParamNames chooses names for the type parameters that StyleGeneric adds to t.F:
+// one for each parameter in t.M for which that is possible.
+// The result maps parameter names to type-parameter names.
+// Each type-parameter name is the parameter name in upper case
+// (e.g. R for r,
+// or for the elements of rs),
+// with a numeric suffix if needed
+// to make it different from every identifier in the function
+// (so that it shadows nothing the function refers to).
+// Methods and function literals cannot have type parameters,
+// so the result is empty for them.
+func (ch Checker) TypeParamNames(t Tuple) map[string]string {
+	if t.F.Recv != nil || t.L != nil {
+		return nil
+	}
+
+	taken := set.New[string]()
+	ast.Inspect(t.F, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			taken.Add(id.Name)
+		}
+		return true
+	})
+
+	var result map[string]string
+	for _, field := range t.F.Type.Params.List {
+		for _, name := range field.Names {
+			if len(t.M[name.Name]) == 0 || !t.Params[name.Name].Generic {
+				continue
+			}
+			var (
+				base = strings.ToUpper(name.Name)
+				tp   = base
+			)
+			if t.Params[name.Name].Elems && len(base) > 1 {
+				// Name the type of one element, e.g. R for rs.
+				base = strings.TrimSuffix(base, "S")
+				tp = base
+			}
+			for n := 2; taken.Has(tp) || types.Universe.Lookup(tp) != nil; n++ {
+				tp = base + strconv.Itoa(n)
+			}
+			taken.Add(tp)
+			if result == nil {
+				result = make(map[string]string)
+			}
+			result[name.Name] = tp
+		}
+	}
+	return result
+}
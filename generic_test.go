@@ -0,0 +1,51 @@
+package decouple
+
+import "testing"
+
+func TestGeneric(t *testing.T) {
+	checker, err := NewCheckerFromDir("_testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tuples, err := checker.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		fn, param string
+		want      bool
+	}{
+		{fn: "F1", param: "r", want: true},
+		{fn: "F4", param: "f", want: true},
+		{fn: "F11", param: "r"},       // switched on
+		{fn: "F17", param: "r"},       // compared
+		{fn: "F64", param: "rc"},      // type-asserted
+		{fn: "ReadOther", param: "f"}, // a method
+		{fn: "F84", param: "f"},       // called with nil
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.fn, func(t *testing.T) {
+			for _, tuple := range tuples {
+				if tuple.F.Name.Name != tc.fn {
+					continue
+				}
+				res := tuple.Params[tc.param]
+				if len(res.Methods) == 0 {
+					t.Fatalf("parameter %s not eligible for decoupling", tc.param)
+				}
+				if res.Generic != tc.want {
+					t.Errorf("got Generic %v, want %v", res.Generic, tc.want)
+				}
+				_, ok := checker.TypeParamNames(tuple)[tc.param]
+				if ok != tc.want {
+					t.Errorf("got type-parameter name %v, want %v", ok, tc.want)
+				}
+				return
+			}
+			t.Fatalf("function %s not found", tc.fn)
+		})
+	}
+}
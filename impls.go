@@ -0,0 +1,335 @@
+package decouple
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"github.com/bobg/errors"
+	"github.com/bobg/go-generics/v3/set"
+	"golang.org/x/tools/go/packages"
+)
+
+// MethodTuple is the result of checking the parameters of a method
+// in an interface type declared in the Checker's packages,
+// together with the corresponding parameters of the methods implementing it.
+// See Checker.CheckInterfaceMethods.
+type MethodTuple struct {
+	// T is the declaration of the interface type.
+	T *ast.TypeSpec
+
+	// F is the method's entry in the method list of T.
+	F *ast.Field
+
+	// P is the package in which T is declared.
+	P *packages.Package
+
+	// Params are the combined results for the parameters of the method,
+	// in order.
+	// A parameter is eligible for decoupling
+	// (in the interface and every implementation at once)
+	// when its Methods are non-empty.
+	Params []ParamResult
+
+	// Impls are Tuples for the declarations of the implementing methods,
+	// sorted by position.
+	// In each one,
+	// M and Params are keyed by the implementation's own parameter names
+	// and have the combined MethodMaps,
+	// so they can be passed to Checker.Fix.
+	Impls []Tuple
+}
+
+// Pos computes the filename and offset
+// of the method name in t.
+func (t MethodTuple) Pos() token.Position {
+	return t.P.Fset.Position(t.F.Names[0].Pos())
+}
+
+// ParamName is a name for parameter i of the method in t:
+// its name in the interface, if it has one,
+// otherwise its name in the first implementation that names it,
+// otherwise its position, e.g. "#2".
+func (t MethodTuple) ParamName(i int) string {
+	if name := paramName(t.F.Type.(*ast.FuncType), i); name != nil && name.Name != "_" {
+		return name.Name
+	}
+	for _, impl := range t.Impls {
+		if name := paramName(impl.F.Type, i); name != nil && name.Name != "_" {
+			return name.Name
+		}
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
+
+// CheckInterfaceMethods checks the methods of the interface types
+// declared in the Checker's packages,
+// looking for parameters with concrete types
+// that could be interfaces in the interface method and all its implementations together,
+// like the tx parameter of a method Store(ctx context.Context, tx *sql.Tx) error
+// whose implementations all use tx only for its ExecContext method.
+// The result is a list of MethodTuples,
+// one for each method with parameters and implementations,
+// in the order of their declarations.
+//
+// The implementations are the methods of the package-level, non-generic named types
+// in the Checker's packages
+// (and their pointer types)
+// that implement the interface.
+// Each one's parameter is analyzed like that of any other function
+// (normally after Check has run, as with CheckFields),
+// and the methods they need are combined.
+// Implementations elsewhere cannot be seen,
+// so a parameter of a method in an exported interface
+// may be needed in ways this does not account for.
+// Generic interfaces are not checked.
+func (ch Checker) CheckInterfaceMethods() (_ []MethodTuple, err error) {
+	defer recoverDerr(&err)
+
+	var concrete []types.Type
+	for _, pkg := range ch.pkgs {
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || tn.IsAlias() {
+				continue
+			}
+			named, ok := tn.Type().(*types.Named)
+			if !ok || named.TypeParams().Len() > 0 || types.IsInterface(named) {
+				continue
+			}
+			concrete = append(concrete, named)
+		}
+	}
+
+	var result []MethodTuple
+	for _, pkg := range ch.pkgs {
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				gendecl, ok := decl.(*ast.GenDecl)
+				if !ok || gendecl.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gendecl.Specs {
+					tspec, ok := spec.(*ast.TypeSpec)
+					if !ok || tspec.Assign.IsValid() || tspec.TypeParams != nil {
+						continue
+					}
+					it, ok := tspec.Type.(*ast.InterfaceType)
+					if !ok {
+						continue
+					}
+					tn, ok := pkg.TypesInfo.Defs[tspec.Name].(*types.TypeName)
+					if !ok {
+						continue
+					}
+					intf, ok := tn.Type().Underlying().(*types.Interface)
+					if !ok || !intf.IsMethodSet() {
+						continue
+					}
+
+					var impls []types.Type
+					for _, typ := range concrete {
+						if types.Implements(typ, intf) || types.Implements(types.NewPointer(typ), intf) {
+							impls = append(impls, typ)
+						}
+					}
+
+					for _, field := range it.Methods.List {
+						if len(field.Names) != 1 {
+							// An embedded interface.
+							continue
+						}
+						m, ok := pkg.TypesInfo.Defs[field.Names[0]].(*types.Func)
+						if !ok || m.Type().(*types.Signature).Params().Len() == 0 {
+							continue
+						}
+						t, err := ch.checkInterfaceMethod(pkg, tspec, field, m, impls)
+						if err != nil {
+							return nil, errors.Wrapf(err, "checking method %s.%s", tspec.Name.Name, m.Name())
+						}
+						if len(t.Impls) > 0 {
+							result = append(result, t)
+						}
+					}
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// checkInterfaceMethod checks the parameters of m,
+// a method in the interface type declared by tspec,
+// in its implementations in the types impls.
+func (ch Checker) checkInterfaceMethod(pkg *packages.Package, tspec *ast.TypeSpec, field *ast.Field, m *types.Func, impls []types.Type) (MethodTuple, error) {
+	var (
+		params = m.Type().(*types.Signature).Params()
+		t      = MethodTuple{T: tspec, F: field, P: pkg, Params: make([]ParamResult, params.Len())}
+		seen   = set.New[*types.Func]()
+		fixed  *Reason // why the signatures cannot change, if they cannot
+	)
+
+	// A method value of the interface method fixes its signature.
+	if use, ok := ch.funcValues[m]; ok {
+		fixed = &Reason{Kind: ReasonFuncValue, Pos: use.pos, Detail: types.TypeString(use.typ, types.RelativeTo(pkg.Types))}
+	}
+
+	for _, typ := range impls {
+		obj, _, _ := types.LookupFieldOrMethod(typ, true, m.Pkg(), m.Name())
+		fn, ok := obj.(*types.Func)
+		if !ok {
+			continue
+		}
+		fn = fn.Origin()
+		if seen.Has(fn) {
+			continue
+		}
+		seen.Add(fn)
+
+		fpkg, fndecl := ch.funcDecl(fn)
+		if fndecl == nil || fndecl.Body == nil {
+			if fixed == nil {
+				fixed = &Reason{Kind: ReasonUnsupported, Detail: "implementation " + fn.FullName() + " has no source"}
+			}
+			continue
+		}
+		if fixed == nil {
+			fixed = ch.otherSignatureConstraint(fpkg, fn, m)
+		}
+		t.Impls = append(t.Impls, Tuple{
+			F:      fndecl,
+			P:      fpkg,
+			M:      make(map[string]MethodMap),
+			Params: make(map[string]ParamResult),
+		})
+	}
+
+	sort.Slice(t.Impls, func(i, j int) bool {
+		return PositionLess(t.Impls[i].Pos(), t.Impls[j].Pos())
+	})
+
+	for i := range t.Params {
+		if fixed != nil {
+			t.Params[i] = ParamResult{Reason: fixed}
+			continue
+		}
+
+		var (
+			combined = ParamResult{Methods: make(MethodMap), Uses: make(map[string][]Use)}
+			each     = make([]ParamResult, len(t.Impls))
+		)
+		for j, impl := range t.Impls {
+			name := paramName(impl.F.Type, i)
+			if name == nil || name.Name == "_" {
+				// The parameter is unused in this implementation.
+				continue
+			}
+			res, err := ch.checkParamEngine(impl.P, impl.F, name, impl.P.TypesInfo.Defs[name])
+			if err != nil {
+				return MethodTuple{}, errors.Wrapf(err, "analyzing parameter %s of %s", name.Name, impl.F.Name.Name)
+			}
+			if res.Reason != nil && res.Reason.Kind == ReasonNoMethods {
+				// This implementation needs nothing from the parameter.
+				continue
+			}
+			if res.Reason != nil {
+				combined = ParamResult{Reason: res.Reason}
+				break
+			}
+			for method, sig := range res.Methods {
+				combined.Methods[method] = sig
+			}
+			for method, uses := range res.Uses {
+				combined.Uses[method] = append(combined.Uses[method], uses...)
+			}
+			combined.Requires = append(combined.Requires, res.Requires...)
+			each[j] = res
+		}
+
+		if combined.Reason == nil {
+			if intf := getType[*types.Interface](params.At(i).Type()); intf != nil && len(combined.Methods) >= intf.NumMethods() {
+				combined = ParamResult{Reason: &Reason{Kind: ReasonNoNarrower}}
+			} else if len(combined.Methods) == 0 {
+				combined = ParamResult{Reason: &Reason{Kind: ReasonNoMethods}}
+			}
+		}
+		t.Params[i] = combined
+
+		for j, impl := range t.Impls {
+			name := paramName(impl.F.Type, i)
+			if name == nil || name.Name == "_" {
+				continue
+			}
+			if combined.Reason != nil {
+				impl.Params[name.Name] = ParamResult{Reason: combined.Reason}
+				continue
+			}
+			impl.M[name.Name] = combined.Methods
+			impl.Params[name.Name] = ParamResult{
+				Methods:  combined.Methods,
+				Uses:     each[j].Uses,
+				Requires: each[j].Requires,
+				Aliases:  each[j].Aliases,
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// otherSignatureConstraint tells why the signature of fn,
+// which implements the interface method m,
+// cannot change along with that of m,
+// if it cannot:
+// because fn is used as a function value,
+// or because a type with fn is used as an interface
+// whose method of the same name is not m.
+func (ch Checker) otherSignatureConstraint(pkg *packages.Package, fn, m *types.Func) *Reason {
+	q := types.RelativeTo(pkg.Types)
+
+	if use, ok := ch.funcValues[fn]; ok {
+		return &Reason{Kind: ReasonFuncValue, Pos: use.pos, Detail: types.TypeString(use.typ, q)}
+	}
+
+	for _, use := range ch.ifaceUses {
+		intf := getType[*types.Interface](use.intf)
+		if intf == nil {
+			continue
+		}
+		for i := 0; i < intf.NumMethods(); i++ {
+			if im := intf.Method(i); im.Name() != fn.Name() || im == m {
+				// Interfaces embedding m's interface have m itself,
+				// and change with it.
+				continue
+			}
+			obj, _, _ := types.LookupFieldOrMethod(use.typ, true, fn.Pkg(), fn.Name())
+			if f, ok := obj.(*types.Func); ok && f.Origin() == fn {
+				return &Reason{Kind: ReasonInterfaceMethod, Pos: use.pos, Detail: types.TypeString(use.intf, q)}
+			}
+		}
+	}
+
+	return nil
+}
+
+// paramName finds the name of parameter i in ftype,
+// or nil if it is unnamed.
+func paramName(ftype *ast.FuncType, i int) *ast.Ident {
+	for _, field := range ftype.Params.List {
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		if i < n {
+			if len(field.Names) == 0 {
+				return nil
+			}
+			return field.Names[i]
+		}
+		i -= n
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package decouple
+
+import "testing"
+
+func TestCheckInterfaceMethods(t *testing.T) {
+	checker, err := NewCheckerFromDir("_testdata/impls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checker.Check(); err != nil {
+		t.Fatal(err)
+	}
+	mts, err := checker.CheckInterfaceMethods()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]MethodTuple)
+	for _, mt := range mts {
+		got[mt.T.Name.Name+"."+mt.F.Names[0].Name] = mt
+	}
+	if _, ok := got["Unimplemented.Do"]; ok {
+		t.Error("got result for method with no implementations")
+	}
+
+	cases := []struct {
+		method, param string
+		want          string // see testResult
+		impls         int
+	}{
+		{method: "Store.Put", param: "tx", want: "[ExecContext]", impls: 2},
+		{method: "Store.Get", param: "tx", want: "[ExecContext QueryRowContext]", impls: 2},
+		{method: "Store.Put", param: "ctx", want: "uses every method of its interface type", impls: 2},
+		{method: "Logger.Log", param: "f", want: "[Sync Write]", impls: 2},
+		{method: "Opener.Open", param: "f", want: "returned as concrete type", impls: 1},
+		{method: "Syncer.SyncTo", param: "f", want: "constrained by interface otherSyncer", impls: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.method+"/"+tc.param, func(t *testing.T) {
+			mt, ok := got[tc.method]
+			if !ok {
+				t.Fatalf("method %s not found", tc.method)
+			}
+			if len(mt.Impls) != tc.impls {
+				t.Errorf("got %d implementations, want %d", len(mt.Impls), tc.impls)
+			}
+			for i, res := range mt.Params {
+				if mt.ParamName(i) != tc.param {
+					continue
+				}
+				testResult(t, res, tc.want)
+				if res.Reason != nil {
+					return
+				}
+
+				// Every implementation changes too.
+				for _, impl := range mt.Impls {
+					fix, err := checker.Fix(impl)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(fix.Edits) == 0 {
+						t.Errorf("no edits for %s at %s", impl.F.Name.Name, impl.Pos())
+					}
+				}
+				return
+			}
+			t.Fatalf("param %s not found", tc.param)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package decouple
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"go/version"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Iter is an iterator type that a slice or map parameter could have instead,
+// because it is only ranged over:
+// iter.Seq[V] or iter.Seq2[K, V].
+// See Checker.CheckIters.
+type Iter struct {
+	// K and V are the types of the values the iterator yields.
+	// K is nil for iter.Seq.
+	K, V types.Type
+
+	// Adapter is the function that callers can use
+	// to turn a value of the parameter's old type into the iterator,
+	// e.g. "slices.Values".
+	Adapter string
+
+	// Edits are the changes to the range statements over the parameter
+	// needed to range over an iter.Seq instead,
+	// as when "for _, v := range s" becomes "for v := range s".
+	Edits []Edit
+}
+
+// TypeString produces the text of the iterator type,
+// e.g. "iter.Seq[string]",
+// using q (which may be nil) to qualify package names as in types.TypeString.
+func (it Iter) TypeString(q types.Qualifier) string {
+	name := "iter"
+	if q != nil {
+		name = q(iterPkg)
+	}
+	if it.K == nil {
+		return name + ".Seq[" + types.TypeString(it.V, q) + "]"
+	}
+	return name + ".Seq2[" + types.TypeString(it.K, q) + ", " + types.TypeString(it.V, q) + "]"
+}
+
+var iterPkg = types.NewPackage("iter", "iter")
+
+// CheckIters checks the slice and map parameters of a function declaration,
+// which should appear in the given package,
+// which should be one of the packages contained in the Checker.
+// The result maps the names of those parameters that are only ranged over
+// to the iterator types they could have instead.
+//
+// Iterator types require Go 1.23,
+// so the result is empty unless the file containing fndecl
+// (normally, by the go directive in its go.mod file)
+// has at least that version.
+// Only parameters written as slice or map types
+// (not variadic ones, or named types)
+// are considered.
+func (ch Checker) CheckIters(pkg *packages.Package, fndecl *ast.FuncDecl) map[string]Iter {
+	if fndecl.Body == nil || !goVersionAtLeast(pkg, fndecl.Pos(), "go1.23") {
+		return nil
+	}
+	if ch.fixedSignature(pkg, fndecl) != nil {
+		return nil
+	}
+
+	var result map[string]Iter
+	for _, field := range fndecl.Type.Params.List {
+		for _, name := range field.Names {
+			if name.Name == "_" {
+				continue
+			}
+			it, ok := checkIter(pkg.TypesInfo, fndecl.Body, field.Type, pkg.TypesInfo.Defs[name])
+			if !ok {
+				continue
+			}
+			if result == nil {
+				result = make(map[string]Iter)
+			}
+			result[name.Name] = it
+		}
+	}
+	return result
+}
+
+// goVersionAtLeast tells whether the Go version of the file containing pos
+// (or else of pkg)
+// is at least v.
+func goVersionAtLeast(pkg *packages.Package, pos token.Pos, v string) bool {
+	pv := pkg.Types.GoVersion()
+	if file := fileFor(pkg, pos); file != nil && pkg.TypesInfo.FileVersions != nil {
+		if fv, ok := pkg.TypesInfo.FileVersions[file]; ok && fv != "" {
+			pv = fv
+		}
+	}
+	return version.IsValid(pv) && version.Compare(pv, v) >= 0
+}
+
+// checkIter checks whether the parameter obj,
+// whose type is written as typeExpr,
+// is used in body only as the operand of range statements.
+func checkIter(info *types.Info, body ast.Node, typeExpr ast.Expr, obj types.Object) (Iter, bool) {
+	var isMap bool
+	switch typeExpr := ast.Unparen(typeExpr).(type) {
+	case *ast.ArrayType:
+		if typeExpr.Len != nil {
+			return Iter{}, false
+		}
+	case *ast.MapType:
+		isMap = true
+	default:
+		return Iter{}, false
+	}
+
+	var (
+		ranges               []*ast.RangeStmt
+		keyUsed, valUsed, ok = false, false, true
+		visit                func(ast.Node) bool
+	)
+	visit = func(n ast.Node) bool {
+		if !ok {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.RangeStmt:
+			if id, isID := n.X.(*ast.Ident); isID && info.Uses[id] == obj {
+				ranges = append(ranges, n)
+				if n.Key != nil && !isBlank(n.Key) {
+					keyUsed = true
+				}
+				if n.Value != nil && !isBlank(n.Value) {
+					valUsed = true
+				}
+				// Visit everything but n.X.
+				for _, child := range []ast.Node{n.Key, n.Value, n.Body} {
+					if child != nil {
+						ast.Inspect(child, visit)
+					}
+				}
+				return false
+			}
+		case *ast.Ident:
+			if info.Uses[n] == obj {
+				ok = false
+			}
+		}
+		return true
+	}
+	ast.Inspect(body, visit)
+	if !ok || len(ranges) == 0 {
+		return Iter{}, false
+	}
+
+	var (
+		typ = types.Unalias(obj.Type())
+		it  Iter
+	)
+	if isMap {
+		m, isM := typ.(*types.Map)
+		if !isM {
+			return Iter{}, false
+		}
+		switch {
+		case keyUsed && valUsed:
+			it = Iter{K: m.Key(), V: m.Elem(), Adapter: "maps.All"}
+		case valUsed:
+			it = Iter{V: m.Elem(), Adapter: "maps.Values"}
+		default:
+			it = Iter{V: m.Key(), Adapter: "maps.Keys"}
+		}
+	} else {
+		s, isS := typ.(*types.Slice)
+		if !isS {
+			return Iter{}, false
+		}
+		if keyUsed {
+			it = Iter{K: types.Typ[types.Int], V: s.Elem(), Adapter: "slices.All"}
+		} else {
+			it = Iter{V: s.Elem(), Adapter: "slices.Values"}
+		}
+	}
+
+	if it.K == nil {
+		// An iter.Seq yields one value,
+		// so loops naming both a key and a value must name just one.
+		for _, r := range ranges {
+			switch {
+			case r.Value == nil:
+			case it.Adapter == "maps.Keys":
+				// Keep the key: "for k, _ := range m" becomes "for k := range m".
+				it.Edits = append(it.Edits, Edit{Pos: r.Key.End(), End: r.Value.End()})
+			default:
+				// Keep the value: "for _, v := range s" becomes "for v := range s".
+				it.Edits = append(it.Edits, Edit{Pos: r.Key.Pos(), End: r.Value.Pos()})
+			}
+		}
+	}
+
+	return it, true
+}
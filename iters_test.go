@@ -0,0 +1,88 @@
+package decouple
+
+import (
+	"go/types"
+	"testing"
+)
+
+func TestCheckIters(t *testing.T) {
+	checker, err := NewCheckerFromDir("_testdata/iters")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tuples, err := checker.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		fn, param     string
+		want, adapter string // want is empty for no suggestion
+	}{
+		{fn: "Join", param: "strs", want: "iter.Seq[string]", adapter: "slices.Values"},
+		{fn: "Number", param: "lines", want: "iter.Seq2[int, string]", adapter: "slices.All"},
+		{fn: "Total", param: "m", want: "iter.Seq[int]", adapter: "maps.Values"},
+		{fn: "Names", param: "m", want: "iter.Seq[string]", adapter: "maps.Keys"},
+		{fn: "Show", param: "m", want: "iter.Seq2[string, int]", adapter: "maps.All"},
+		{fn: "Count", param: "strs"},
+		{fn: "First", param: "strs"},
+		{fn: "Readers", param: "rs"},
+		{fn: "Callback", param: "strs"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.fn, func(t *testing.T) {
+			for _, tuple := range tuples {
+				if tuple.F.Name.Name != tc.fn {
+					continue
+				}
+				it, ok := tuple.I[tc.param]
+				if tc.want == "" {
+					if ok {
+						t.Errorf("got %s, want no suggestion", it.TypeString(nil))
+					}
+					return
+				}
+				if !ok {
+					t.Fatal("got no suggestion")
+				}
+				if got := it.TypeString(types.RelativeTo(tuple.P.Types)); got != tc.want {
+					t.Errorf("got %s, want %s", got, tc.want)
+				}
+				if it.Adapter != tc.adapter {
+					t.Errorf("got adapter %s, want %s", it.Adapter, tc.adapter)
+				}
+
+				errs, err := checker.Verify(tuple)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(errs) > 0 {
+					t.Errorf("got errors %v, want none", errs)
+				}
+				return
+			}
+			t.Fatalf("function %s not found", tc.fn)
+		})
+	}
+}
+
+func TestCheckItersOldGo(t *testing.T) {
+	// The go.mod file in _testdata declares Go 1.19,
+	// which cannot range over functions.
+	checker, err := NewCheckerFromDir("_testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tuples, err := checker.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tuple := range tuples {
+		if len(tuple.I) > 0 {
+			t.Errorf("got iterator suggestions for %s, want none", tuple.F.Name.Name)
+		}
+	}
+}
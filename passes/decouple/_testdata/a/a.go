@@ -0,0 +1,48 @@
+package a
+
+import (
+	"io"
+	"os"
+)
+
+func ReadAll(f *os.File) ([]byte, error) { // want `parameter f of ReadAll could be io.Reader` ReadAll:"f: Read"
+	return io.ReadAll(f)
+}
+
+func ReadClose(rc *os.File) ([]byte, error) { // want `parameter rc of ReadClose could be io.ReadCloser` ReadClose:"rc: Close, Read"
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func Name(f *os.File) string { // want `parameter f of Name could be an interface with methods \[Name\]` Name:"f: Name"
+	return f.Name()
+}
+
+func Split(r, f *os.File) ([]byte, *os.File, error) { // want `parameter r of Split could be io.Reader` Split:"r: Read"
+	b, err := io.ReadAll(r)
+	return b, Concrete(f), err
+}
+
+func Concrete(f *os.File) *os.File {
+	return f
+}
+
+func Drain(rc io.ReadCloser) ([]byte, error) { // want `parameter rc of Drain could be io.Reader; narrowing loses optional interface io.Closer` Drain:"rc: Read"
+	if c, ok := rc.(io.Closer); ok {
+		defer c.Close()
+	}
+	return io.ReadAll(rc)
+}
+
+func ReadGeneric[RC io.ReadCloser](rc RC) ([]byte, error) { // want `type parameter RC of ReadGeneric could be constrained by io.Reader`
+	return io.ReadAll(rc)
+}
+
+func ReadEach(rs []*os.File) error { // want `elements of parameter rs of ReadEach could be io.Reader`
+	for _, r := range rs {
+		if _, err := io.ReadAll(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+package b
+
+import "os"
+
+func Name(f *os.File) string { // want `parameter f of Name could be an interface with methods \[Name\]` Name:"f: Name"
+	return f.Name()
+}
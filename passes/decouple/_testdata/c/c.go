@@ -0,0 +1,14 @@
+package c
+
+import (
+	"fmt"
+	"os"
+)
+
+func Read(f *os.File, buf []byte) (int, error) { // want `parameter f of Read could be io.Reader` Read:"f: Read"
+	return f.Read(buf)
+}
+
+func Print(x any) {
+	fmt.Println(x)
+}
@@ -0,0 +1,11 @@
+package d
+
+import (
+	"os"
+
+	"a/a"
+)
+
+func ReadClose(f *os.File) ([]byte, error) { // want `parameter f of ReadClose could be io.ReadCloser \(if parameter rc of a.ReadClose is decoupled too\)` ReadClose:"f: Close, Read"
+	return a.ReadClose(f)
+}
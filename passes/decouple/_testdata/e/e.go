@@ -0,0 +1,17 @@
+//go:build go1.23
+
+// Package e needs Go 1.23 for range-over-func,
+// which its build constraint provides.
+package e
+
+import (
+	"strings"
+)
+
+func Join(strs []string) string { // want `parameter strs of Join could be iter.Seq\[string\] \(callers can use slices.Values\)`
+	var buf strings.Builder
+	for _, s := range strs {
+		buf.WriteString(s)
+	}
+	return buf.String()
+}
@@ -0,0 +1,13 @@
+// Package f has a parameter whose new type refers to the package of its old one,
+// which must stay imported.
+package f
+
+import "net/http"
+
+func Session(r *http.Request) string { // want `parameter r of Session could be an interface with methods \[Cookie\]` Session:"r: Cookie"
+	c, err := r.Cookie("session")
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
@@ -0,0 +1,357 @@
+// Package decouple defines an Analyzer that reports overspecified function parameters.
+//
+// It is a wrapper around the Checker in github.com/bobg/decouple
+// that can be run with go vet -vettool,
+// or alongside other analyzers in a multichecker, nogo, golangci-lint, etc.
+//
+// Each finding is a diagnostic at the position of the parameter,
+// with a suggested fix that rewrites the parameter's type.
+//
+// The methods needed by each function's eligible parameters
+// are exported as facts,
+// so that a parameter passed to a function in another package
+// can be decoupled when the callee's parameter can.
+//
+// Unlike the decouple command,
+// which loads all the packages it checks together,
+// the Analyzer sees only one package (and facts about its dependencies) at a time.
+// So it cannot see uses in other packages
+// that fix a function's signature:
+// a method whose type is used in another package as an interface including it,
+// or a function used as a value in another package.
+// Such functions are still reported,
+// and applying the suggested fix breaks the build.
+// This matters mostly for exported functions and methods.
+package decouple
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/bobg/go-generics/v3/maps"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/bobg/decouple"
+)
+
+// Analyzer reports function parameters with concrete types
+// that could be interfaces instead.
+var Analyzer = &analysis.Analyzer{
+	Name: "decouple",
+	Doc:  "find overspecified function parameters\n\nThe decouple analyzer reports function parameters whose types are more specific than they need to be.\nFor example, a *os.File parameter that is used only for its Read method could be an io.Reader.",
+	URL:  "https://pkg.go.dev/github.com/bobg/decouple/passes/decouple",
+	Run:  run,
+
+	FactTypes: []analysis.Fact{new(paramsFact)},
+}
+
+// paramsFact is exported for each function with parameters eligible for decoupling.
+type paramsFact struct {
+	Params []paramFact
+}
+
+// paramFact records the methods needed by one parameter of a function.
+// Their signatures are recovered from the parameter's declared type.
+type paramFact struct {
+	Index   int
+	Name    string
+	Methods []string // sorted
+}
+
+func (*paramsFact) AFact() {}
+
+func (f *paramsFact) String() string {
+	var strs []string
+	for _, p := range f.Params {
+		strs = append(strs, p.Name+": "+strings.Join(p.Methods, ", "))
+	}
+	return strings.Join(strs, "; ")
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	var (
+		pkg     = packageFromPass(pass)
+		checker = decouple.NewCheckerFromPackages([]*packages.Package{pkg})
+	)
+
+	for _, of := range pass.AllObjectFacts() {
+		fn, mms := methodMaps(of)
+		for i, mm := range mms {
+			checker.Assume(fn, i, mm)
+		}
+	}
+
+	tuples, err := checker.CheckPackage(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	r := reporter{pass: pass, checker: checker}
+	for _, tuple := range tuples {
+		var fact paramsFact
+		for i, name := range paramNames(tuple.F) {
+			if name == nil {
+				continue
+			}
+			mm := tuple.M[name.Name]
+			if len(mm) == 0 {
+				continue
+			}
+			r.report(tuple, name, mm)
+			if tuple.Params[name.Name].Elems {
+				// The methods are for the parameter's elements,
+				// which callers in other packages can't pass on their own.
+				continue
+			}
+
+			methods := maps.Keys(mm)
+			sort.Strings(methods)
+			fact.Params = append(fact.Params, paramFact{Index: i, Name: name.Name, Methods: methods})
+		}
+		for _, name := range paramNames(tuple.F) {
+			if name == nil {
+				continue
+			}
+			if it, ok := tuple.I[name.Name]; ok {
+				r.reportIter(tuple, name, it)
+			}
+		}
+		if tparams := tuple.F.Type.TypeParams; tparams != nil {
+			for _, field := range tparams.List {
+				for _, name := range field.Names {
+					if mm, ok := tuple.T[name.Name]; ok {
+						r.reportTypeParam(tuple, name, mm)
+					}
+				}
+			}
+		}
+		if len(fact.Params) == 0 {
+			continue
+		}
+		if fn, ok := pass.TypesInfo.Defs[tuple.F.Name].(*types.Func); ok {
+			pass.ExportObjectFact(fn, &fact)
+		}
+	}
+
+	return nil, nil
+}
+
+// paramNames returns the parameter names of fndecl,
+// in order,
+// with nil for each unnamed parameter.
+func paramNames(fndecl *ast.FuncDecl) []*ast.Ident {
+	var result []*ast.Ident
+	for _, field := range fndecl.Type.Params.List {
+		if len(field.Names) == 0 {
+			result = append(result, nil)
+			continue
+		}
+		result = append(result, field.Names...)
+	}
+	return result
+}
+
+// methodMaps recovers, from a fact exported for a function in another package,
+// the function and the MethodMaps for its parameters,
+// keyed by parameter index.
+func methodMaps(of analysis.ObjectFact) (*types.Func, map[int]decouple.MethodMap) {
+	fn, ok := of.Object.(*types.Func)
+	if !ok {
+		return nil, nil
+	}
+	var (
+		fact   = of.Fact.(*paramsFact)
+		params = fn.Type().(*types.Signature).Params()
+		result = make(map[int]decouple.MethodMap)
+	)
+
+PARAMS:
+	for _, p := range fact.Params {
+		if p.Index >= params.Len() {
+			continue
+		}
+		var (
+			ptype = params.At(p.Index).Type()
+			mm    = make(decouple.MethodMap)
+		)
+		for _, method := range p.Methods {
+			obj, _, _ := types.LookupFieldOrMethod(ptype, true, fn.Pkg(), method)
+			m, ok := obj.(*types.Func)
+			if !ok {
+				continue PARAMS
+			}
+			mm[method] = m.Type().(*types.Signature)
+		}
+		result[p.Index] = mm
+	}
+	return fn, result
+}
+
+type reporter struct {
+	pass    *analysis.Pass
+	checker decouple.Checker
+}
+
+func (r reporter) report(tuple decouple.Tuple, name *ast.Ident, mm decouple.MethodMap) {
+	var (
+		desc = r.describe(mm)
+		msg  = fmt.Sprintf("parameter %s of %s could be %s", name.Name, tuple.F.Name.Name, desc)
+		fix  = fmt.Sprintf("Change the type of %s to %s", name.Name, desc)
+	)
+	if tuple.Params[name.Name].Elems {
+		msg = fmt.Sprintf("elements of parameter %s of %s could be %s", name.Name, tuple.F.Name.Name, desc)
+		fix = fmt.Sprintf("Change the element type of %s to %s", name.Name, desc)
+	}
+	if requires := tuple.Params[name.Name].Requires; len(requires) > 0 {
+		var strs []string
+		for _, ref := range requires {
+			strs = append(strs, fmt.Sprintf("%s of %s", ref.Name, r.funcName(ref)))
+		}
+		msg += fmt.Sprintf(" (if parameter %s is decoupled too)", strings.Join(strs, ", "))
+	}
+	for _, u := range tuple.Params[name.Name].Upgrades {
+		msg += "; narrowing loses optional interface " + u.Interface
+	}
+
+	diag := analysis.Diagnostic{
+		Pos:     name.Pos(),
+		End:     name.End(),
+		Message: msg,
+	}
+
+	// Fix just this one parameter.
+	tuple.M = map[string]decouple.MethodMap{name.Name: mm}
+	tuple.T = nil
+	tuple.I = nil
+	r.suggestFix(&diag, tuple, fix)
+
+	r.pass.Report(diag)
+}
+
+// reportTypeParam reports a type parameter whose constraint could be narrowed.
+func (r reporter) reportTypeParam(tuple decouple.Tuple, name *ast.Ident, mm decouple.MethodMap) {
+	desc := r.describe(mm)
+
+	diag := analysis.Diagnostic{
+		Pos:     name.Pos(),
+		End:     name.End(),
+		Message: fmt.Sprintf("type parameter %s of %s could be constrained by %s", name.Name, tuple.F.Name.Name, desc),
+	}
+
+	// Fix just this one type parameter.
+	tuple.M = nil
+	tuple.T = map[string]decouple.MethodMap{name.Name: mm}
+	tuple.I = nil
+	r.suggestFix(&diag, tuple, fmt.Sprintf("Change the constraint of %s to %s", name.Name, desc))
+
+	r.pass.Report(diag)
+}
+
+// reportIter reports a parameter that could be an iterator.
+func (r reporter) reportIter(tuple decouple.Tuple, name *ast.Ident, it decouple.Iter) {
+	desc := it.TypeString(types.RelativeTo(r.pass.Pkg))
+
+	diag := analysis.Diagnostic{
+		Pos:     name.Pos(),
+		End:     name.End(),
+		Message: fmt.Sprintf("parameter %s of %s could be %s (callers can use %s)", name.Name, tuple.F.Name.Name, desc, it.Adapter),
+	}
+
+	// Fix just this one parameter.
+	tuple.M = nil
+	tuple.T = nil
+	tuple.I = map[string]decouple.Iter{name.Name: it}
+	r.suggestFix(&diag, tuple, fmt.Sprintf("Change the type of %s to %s", name.Name, desc))
+
+	r.pass.Report(diag)
+}
+
+// describe produces a description of the type needed for mm:
+// the name of an existing interface type,
+// an interface with the given methods,
+// or any.
+func (r reporter) describe(mm decouple.MethodMap) string {
+	if len(mm) == 0 {
+		return "any"
+	}
+	if intfName := r.checker.NameForMethods(mm); intfName != "" {
+		return intfName
+	}
+	methods := maps.Keys(mm)
+	sort.Strings(methods)
+	return fmt.Sprintf("an interface with methods %v", methods)
+}
+
+// suggestFix adds to diag the fix for tuple, if there is one.
+func (r reporter) suggestFix(diag *analysis.Diagnostic, tuple decouple.Tuple, msg string) {
+	fix, err := r.checker.Fix(tuple)
+	if err != nil {
+		return
+	}
+	var edits []analysis.TextEdit
+	for _, e := range append(fix.Edits, fix.ImportEdits()...) {
+		edits = append(edits, analysis.TextEdit{Pos: e.Pos, End: e.End, NewText: []byte(e.NewText)})
+	}
+	diag.SuggestedFixes = []analysis.SuggestedFix{{
+		Message:   msg,
+		TextEdits: edits,
+	}}
+}
+
+// funcName produces the name of the function in ref
+// as it would be written in the package being analyzed.
+func (r reporter) funcName(ref decouple.ParamRef) string {
+	var (
+		fn  = ref.Func
+		sig = fn.Type().(*types.Signature)
+	)
+	if recv := sig.Recv(); recv != nil {
+		return fmt.Sprintf("(%s).%s", types.TypeString(recv.Type(), types.RelativeTo(r.pass.Pkg)), fn.Name())
+	}
+	if fn.Pkg() != r.pass.Pkg {
+		return fn.Pkg().Name() + "." + fn.Name()
+	}
+	return fn.Name()
+}
+
+// packageFromPass presents the package being analyzed in the given pass
+// in the form that a decouple.Checker expects.
+// Only the package itself has syntax;
+// its imports have type information only.
+func packageFromPass(pass *analysis.Pass) *packages.Package {
+	pkg := &packages.Package{
+		ID:         pass.Pkg.Path(),
+		Name:       pass.Pkg.Name(),
+		PkgPath:    pass.Pkg.Path(),
+		Fset:       pass.Fset,
+		Syntax:     pass.Files,
+		Types:      pass.Pkg,
+		TypesInfo:  pass.TypesInfo,
+		TypesSizes: pass.TypesSizes,
+	}
+	pkg.Imports = importsFromTypes(pass.Pkg.Imports(), make(map[*types.Package]*packages.Package))
+	return pkg
+}
+
+func importsFromTypes(imports []*types.Package, seen map[*types.Package]*packages.Package) map[string]*packages.Package {
+	result := make(map[string]*packages.Package)
+	for _, imp := range imports {
+		ipkg, ok := seen[imp]
+		if !ok {
+			ipkg = &packages.Package{
+				ID:      imp.Path(),
+				Name:    imp.Name(),
+				PkgPath: imp.Path(),
+				Types:   imp,
+			}
+			seen[imp] = ipkg
+			ipkg.Imports = importsFromTypes(imp.Imports(), seen)
+		}
+		result[imp.Path()] = ipkg
+	}
+	return result
+}
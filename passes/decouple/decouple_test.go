@@ -0,0 +1,11 @@
+package decouple
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, "_testdata", Analyzer, "./...")
+}
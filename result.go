@@ -0,0 +1,187 @@
+package decouple
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Reason explains why a function parameter is not eligible for decoupling.
+type Reason struct {
+	Kind ReasonKind
+
+	// Pos is the position of the code that prevents decoupling.
+	// It is the zero Position for ReasonNoMethods, ReasonNoNarrower, and ReasonZeroValue.
+	Pos token.Position
+
+	// Detail is extra information whose meaning depends on Kind,
+	// e.g. the operator for ReasonArithmetic
+	// or the name of the called function for ReasonConcreteParam.
+	Detail string
+}
+
+// ReasonKind is the type of Reason.Kind.
+type ReasonKind int
+
+// Values for ReasonKind.
+const (
+	ReasonUnsupported        ReasonKind = iota // used in a way decouple does not understand
+	ReasonNoMethods                            // no methods are needed
+	ReasonNoNarrower                           // an interface-typed parameter that needs all of its methods
+	ReasonArithmetic                           // used as an operand of an arithmetic or logical operator
+	ReasonUnaryOp                              // used as the operand of a unary operator, including dereferencing and channel receive
+	ReasonConcreteComparison                   // compared to a value of concrete type
+	ReasonConcreteParam                        // passed to a function parameter of concrete type
+	ReasonConcreteAssign                       // assigned to a variable of concrete type
+	ReasonConcreteReturn                       // returned as a concrete type
+	ReasonConcreteSend                         // sent on a channel with a concrete element type
+	ReasonConcreteElement                      // used as an element or key of concrete type in a composite literal or index expression
+	ReasonFieldAccess                          // a field was selected
+	ReasonConversion                           // converted to another type
+	ReasonCall                                 // called as a function
+	ReasonIndex                                // indexed or sliced
+	ReasonRange                                // ranged over
+	ReasonChannel                              // used as a channel
+	ReasonCondition                            // used as a boolean condition
+	ReasonVariadic                             // passed as the final argument of a variadic call with "..."
+	ReasonDoesNotCompile                       // the suggested change does not compile (see Checker.Verify)
+	ReasonInterfaceMethod                      // a method whose signature is needed to satisfy an interface the type is used as
+	ReasonFuncValue                            // a function used as a value (not just called), whose signature is fixed by the type it's used as
+	ReasonAddress                              // its address is taken and used where a pointer to an interface would not do
+	ReasonZeroValue                            // a struct field or package-level variable whose type has a usable zero value, which an interface's would not be
+)
+
+var reasonFormats = map[ReasonKind]string{
+	ReasonUnsupported:        "unsupported use (%s)",
+	ReasonNoMethods:          "no methods used",
+	ReasonNoNarrower:         "uses every method of its interface type",
+	ReasonArithmetic:         "used in arithmetic (%s)",
+	ReasonUnaryOp:            "used with unary operator %s",
+	ReasonConcreteComparison: "compared to concrete value",
+	ReasonConcreteParam:      "passed to concrete param of %s",
+	ReasonConcreteAssign:     "assigned to concrete variable",
+	ReasonConcreteReturn:     "returned as concrete type",
+	ReasonConcreteSend:       "sent on channel of concrete type",
+	ReasonConcreteElement:    "used as concrete element or key",
+	ReasonFieldAccess:        "field access (%s)",
+	ReasonConversion:         "converted to %s",
+	ReasonCall:               "called as a function",
+	ReasonIndex:              "indexed or sliced",
+	ReasonRange:              "ranged over",
+	ReasonChannel:            "used as a channel",
+	ReasonCondition:          "used as a boolean condition",
+	ReasonVariadic:           `passed as "..." argument`,
+	ReasonDoesNotCompile:     "suggested change does not compile: %s",
+	ReasonInterfaceMethod:    "constrained by interface %s",
+	ReasonFuncValue:          "used as function value of type %s",
+	ReasonAddress:            "address used as %s",
+	ReasonZeroValue:          "zero value of %s is usable",
+}
+
+// String produces a description of the reason,
+// e.g. "used in arithmetic (+) at foo.go:12:9".
+func (r Reason) String() string {
+	format, ok := reasonFormats[r.Kind]
+	if !ok {
+		format = "unknown reason"
+	}
+	s := format
+	if strings.Contains(format, "%s") {
+		s = fmt.Sprintf(format, r.Detail)
+	}
+	if r.Pos.IsValid() {
+		s += " at " + r.Pos.String()
+	}
+	return s
+}
+
+// ParamResult is the detailed result of checking a single function parameter.
+// See Checker.CheckParamDetail.
+type ParamResult struct {
+	// Methods is the set of methods the parameter needs
+	// if it is eligible for decoupling,
+	// otherwise nil.
+	Methods MethodMap
+
+	// Uses maps each method in Methods
+	// to the places in the function body that require it.
+	Uses map[string][]Use
+
+	// Reason tells why the parameter is not eligible for decoupling.
+	// It is nil when Methods is non-nil.
+	Reason *Reason
+
+	// Upgrades are the optional interfaces
+	// that code receiving the parameter checks for dynamically
+	// and that Methods does not include.
+	Upgrades []Upgrade
+
+	// Generic tells whether the parameter could instead get the type of a new type parameter
+	// constrained by the interface type
+	// (see StyleGeneric)
+	// without breaking the function's body
+	// or any call to it in the Checker's packages.
+	Generic bool
+
+	// Elems tells whether Methods are needed by the elements of the parameter
+	// (a slice, array, map, or channel, or a variadic parameter)
+	// rather than by the parameter itself.
+	// The suggested type is then the parameter's type with a new element type,
+	// e.g. []io.Reader instead of []*os.File
+	// (see Tuple.WithElem),
+	// and callers must convert their arguments element by element;
+	// or, with StyleGeneric, []R for a new type parameter R,
+	// which needs no conversion.
+	Elems bool
+
+	// Aliases are the identifiers declaring the local variables
+	// that the parameter
+	// (or, with Elems, each of its elements)
+	// is copied into.
+	// Their uses count as uses of the parameter,
+	// and when the parameter's type changes theirs must too.
+	Aliases []*ast.Ident
+
+	// Requires lists the parameters of other functions
+	// that this one is passed to
+	// and that must be decoupled too
+	// for this suggestion to be valid.
+	Requires []ParamRef
+}
+
+// ParamRef identifies a parameter of a function.
+type ParamRef struct {
+	Func *types.Func
+	Name string
+
+	// F and P are the function's declaration and the package containing it.
+	// They are nil when the function's syntax is not available,
+	// as when its result was supplied with Checker.Assume.
+	F *ast.FuncDecl
+	P *packages.Package
+}
+
+// Use is a place where a parameter is used in a way that requires a method.
+type Use struct {
+	Pos token.Position
+
+	// Via describes how the use requires the method,
+	// e.g. "io.ReadAll" when the parameter is passed to io.ReadAll,
+	// or "return as io.ReadCloser".
+	// It is empty when the method is selected directly
+	// (as in param.Method(...)).
+	Via string
+}
+
+// String produces a description of the use,
+// e.g. "via io.ReadAll at handle.go:40:21".
+func (u Use) String() string {
+	if u.Via == "" {
+		return "at " + u.Pos.String()
+	}
+	return "via " + u.Via + " at " + u.Pos.String()
+}
@@ -0,0 +1,245 @@
+package decouple
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/bobg/go-generics/v3/maps"
+)
+
+func TestReasons(t *testing.T) {
+	checker, err := NewCheckerFromDir("_testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tuples, err := checker.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		fn, param string
+		want      ReasonKind
+		detail    string
+	}{
+		{fn: "F3", param: "lf", want: ReasonFieldAccess, detail: ".N"},
+		{fn: "F55", param: "f", want: ReasonConcreteAssign},
+		{fn: "F57", param: "f", want: ReasonConcreteReturn},
+		{fn: "ReadFile", param: "f", want: ReasonInterfaceMethod, detail: "fileReader"},
+		{fn: "CloseFile", param: "f", want: ReasonInterfaceMethod, detail: "fileCloser"},
+		{fn: "F60", param: "f", want: ReasonFuncValue, detail: "func(*os.File) ([]byte, error)"},
+		{fn: "F62", param: "f", want: ReasonFuncValue, detail: "f62"},
+		{fn: "F53", param: "lf", want: ReasonConcreteParam, detail: "F3"},
+		{fn: "F65", param: "f", want: ReasonAddress, detail: "**os.File"},
+		{fn: "F69", param: "f", want: ReasonConcreteParam, detail: "readAllGeneric[*os.File]"},
+		{fn: "F70", param: "f", want: ReasonConcreteParam, detail: "pickGeneric"},
+		{fn: "F67", param: "f", want: ReasonAddress, detail: "**os.File"},
+		{fn: "F66", param: "f", want: ReasonAddress, detail: "any"},
+		{fn: "F81", param: "f", want: ReasonAddress, detail: "any"},
+		{fn: "F54", param: "fn", want: ReasonConversion, detail: "f54"},
+		{fn: "F9", param: "i", want: ReasonConversion, detail: "int"},
+		{fn: "F18", param: "f", want: ReasonConcreteComparison},
+		{fn: "F19", param: "f", want: ReasonCall},
+		{fn: "F23", param: "f", want: ReasonConcreteReturn},
+		{fn: "F30", param: "x", want: ReasonNoNarrower},
+		{fn: "F34", param: "ch", want: ReasonChannel},
+		{fn: "F38", param: "x", want: ReasonArithmetic, detail: "+"},
+		{fn: "F44", param: "s", want: ReasonIndex},
+		{fn: "F74", param: "fs", want: ReasonConcreteParam, detail: "append"},
+		{fn: "F75", param: "fs", want: ReasonIndex},
+		{fn: "F49", param: "n", want: ReasonArithmetic, detail: "++"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.fn+"_"+tc.param, func(t *testing.T) {
+			for _, tuple := range tuples {
+				if tuple.F.Name.Name != tc.fn {
+					continue
+				}
+				res, ok := tuple.Params[tc.param]
+				if !ok {
+					t.Fatalf("no result for %s", tc.param)
+				}
+				if res.Methods != nil {
+					t.Fatalf("got methods %v, want none", res.Methods)
+				}
+				if res.Reason == nil {
+					t.Fatal("got no reason")
+				}
+				if res.Reason.Kind != tc.want || res.Reason.Detail != tc.detail {
+					t.Errorf("got %s (kind %d), want kind %d with detail %q", res.Reason, res.Reason.Kind, tc.want, tc.detail)
+				}
+				return
+			}
+			t.Fatalf("function %s not found", tc.fn)
+		})
+	}
+}
+
+func TestAliases(t *testing.T) {
+	t.Run("syntax", func(t *testing.T) { testAliases(t, EngineSyntax) })
+	t.Run("ssa", func(t *testing.T) { testAliases(t, EngineSSA) })
+}
+
+func testAliases(t *testing.T, engine Engine) {
+	checker, err := NewCheckerFromDir("_testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker.Engine = engine
+
+	tuples, err := checker.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		fn      string
+		aliases []string // or nil for a ReasonConcreteAssign result
+	}{
+		{fn: "F4", aliases: []string{"f2"}},
+		{fn: "F78"},
+		{fn: "F79"},
+		{fn: "F80", aliases: []string{"g"}},
+		{fn: "F83", aliases: []string{"g"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.fn, func(t *testing.T) {
+			for _, tuple := range tuples {
+				if tuple.F.Name.Name != tc.fn {
+					continue
+				}
+				res := tuple.Params["f"]
+				if tc.aliases == nil {
+					if res.Reason == nil || res.Reason.Kind != ReasonConcreteAssign {
+						t.Errorf("got %v, want a concrete assignment", res.Reason)
+					}
+					return
+				}
+				var got []string
+				for _, id := range res.Aliases {
+					got = append(got, id.Name)
+				}
+				if !slices.Equal(got, tc.aliases) {
+					t.Errorf("got aliases %v, want %v", got, tc.aliases)
+				}
+				return
+			}
+			t.Fatalf("function %s not found", tc.fn)
+		})
+	}
+}
+
+func TestUses(t *testing.T) {
+	checker, err := NewCheckerFromDir("_testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tuples, err := checker.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		fn, param, method string
+		wantVia           []string
+	}{
+		{fn: "F6", param: "f", method: "Close", wantVia: []string{"F7"}},
+		{fn: "F7", param: "rc", method: "Close", wantVia: []string{""}},
+		{fn: "F7", param: "rc", method: "Read", wantVia: []string{"io.ReadAll"}},
+		{fn: "F17", param: "r", method: "Read", wantVia: []string{"comparison with io.Reader", "io.ReadAll"}},
+		{fn: "F24", param: "rc", method: "Close", wantVia: []string{"return as io.ReadCloser"}},
+		{fn: "F48", param: "f", method: "Read", wantVia: []string{"element as io.Reader"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.fn+"_"+tc.param+"_"+tc.method, func(t *testing.T) {
+			for _, tuple := range tuples {
+				if tuple.F.Name.Name != tc.fn {
+					continue
+				}
+				uses := tuple.Params[tc.param].Uses[tc.method]
+				if len(uses) != len(tc.wantVia) {
+					t.Fatalf("got %d uses, want %d", len(uses), len(tc.wantVia))
+				}
+				for i, use := range uses {
+					if use.Via != tc.wantVia[i] {
+						t.Errorf("use %d: got via %q, want %q", i, use.Via, tc.wantVia[i])
+					}
+					if !use.Pos.IsValid() {
+						t.Errorf("use %d: invalid position", i)
+					}
+				}
+				return
+			}
+			t.Fatalf("function %s not found", tc.fn)
+		})
+	}
+}
+
+func TestUpgrades(t *testing.T) {
+	checker, err := NewCheckerFromDir("_testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tuples, err := checker.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		fn, param string
+		want      []string
+	}{
+		{fn: "F63", param: "r", want: []string{"io.WriterTo"}},
+		{fn: "F64", param: "rc", want: []string{"io.Closer"}},
+		{fn: "F7", param: "rc"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.fn+"_"+tc.param, func(t *testing.T) {
+			for _, tuple := range tuples {
+				if tuple.F.Name.Name != tc.fn {
+					continue
+				}
+				var got []string
+				for _, u := range tuple.Params[tc.param].Upgrades {
+					got = append(got, u.Interface)
+				}
+				if !slices.Equal(got, tc.want) {
+					t.Errorf("got %v, want %v", got, tc.want)
+				}
+				return
+			}
+			t.Fatalf("function %s not found", tc.fn)
+		})
+	}
+}
+
+// testResult checks res,
+// the result of checking a parameter, field, or variable,
+// against want:
+// the sorted names of its methods (as in "[Close Read]"),
+// or a prefix of its reason.
+func testResult(t *testing.T, res ParamResult, want string) {
+	t.Helper()
+
+	if res.Reason != nil {
+		if got := res.Reason.String(); !strings.HasPrefix(got, want) {
+			t.Errorf("got reason %q, want %q", got, want)
+		}
+		return
+	}
+	methods := maps.Keys(res.Methods)
+	sort.Strings(methods)
+	if got := fmt.Sprint(methods); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,682 @@
+package decouple
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/bobg/go-generics/v3/set"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+)
+
+// Engine selects how a Checker analyzes function bodies.
+type Engine int
+
+const (
+	// EngineSyntax analyzes the syntax trees of function bodies.
+	// This is the default.
+	EngineSyntax Engine = iota
+
+	// EngineSSA analyzes the SSA form of function bodies
+	// (see "golang.org/x/tools/go/ssa").
+	// It follows a parameter's value through phis,
+	// stores to and loads from local variables,
+	// closures,
+	// and method values,
+	// and rejects any use of the value that it does not understand.
+	EngineSSA
+)
+
+// ssaPackage builds the SSA form of pkg,
+// with its imports represented by their type information only.
+// Results are cached in the Checker.
+func (ch Checker) ssaPackage(pkg *packages.Package) *ssa.Package {
+	if p, ok := ch.ssaPkgs[pkg]; ok {
+		return p
+	}
+
+	prog := ssa.NewProgram(pkg.Fset, ssa.GlobalDebug)
+
+	created := set.New[*types.Package]()
+	var create func([]*types.Package)
+	create = func(tpkgs []*types.Package) {
+		for _, tpkg := range tpkgs {
+			if created.Has(tpkg) {
+				continue
+			}
+			created.Add(tpkg)
+			prog.CreatePackage(tpkg, nil, nil, true)
+			create(tpkg.Imports())
+		}
+	}
+	create(pkg.Types.Imports())
+
+	result := prog.CreatePackage(pkg.Types, pkg.Syntax, pkg.TypesInfo, false)
+	result.Build()
+
+	if ch.ssaPkgs != nil {
+		ch.ssaPkgs[pkg] = result
+	}
+	return result
+}
+
+// checkParamSSA is the EngineSSA implementation of CheckParamDetail.
+func (ch Checker) checkParamSSA(pkg *packages.Package, fndecl *ast.FuncDecl, obj types.Object) (ParamResult, error) {
+	fnobj, ok := pkg.TypesInfo.Defs[fndecl.Name].(*types.Func)
+	if !ok {
+		return ParamResult{}, fmt.Errorf("no def found for %s", fndecl.Name.Name)
+	}
+	fn := ch.ssaPackage(pkg).Prog.FuncValue(fnobj)
+	if fn == nil {
+		return ParamResult{}, fmt.Errorf("no SSA function found for %s", fndecl.Name.Name)
+	}
+
+	var param *ssa.Parameter
+	for _, p := range fn.Params {
+		if p.Object() == obj {
+			param = p
+			break
+		}
+	}
+	if param == nil {
+		// The parameter is unused, so SSA dropped its object.
+		return ParamResult{Reason: &Reason{Kind: ReasonNoMethods}}, nil
+	}
+
+	var (
+		intf = getType[*types.Interface](obj.Type())
+		mm   MethodMap
+	)
+	if intf != nil {
+		mm = make(MethodMap)
+		addMethodsToMap(intf, mm)
+	}
+
+	a := &ssaAnalyzer{
+		pkg:        pkg,
+		fndecl:     fndecl,
+		obj:        obj,
+		objmethods: mm,
+		methods:    make(MethodMap),
+		uses:       make(map[string][]Use),
+		summaries:  ch.summaries,
+		seen:       set.New[ssa.Value](),
+		aliases:    set.New[types.Object](),
+		refs:       make(map[types.Object][]*ssa.DebugRef),
+		defs:       make(map[types.Object]*ast.Ident),
+		specs:      make(map[types.Object]*ast.ValueSpec),
+		explicit:   set.New[token.Pos](),
+	}
+	a.index(fn)
+
+	if !a.run(param) {
+		return ParamResult{Reason: a.reason}, nil
+	}
+
+	if len(a.objmethods) > 0 && len(a.methods) >= len(a.objmethods) {
+		return ParamResult{Reason: &Reason{Kind: ReasonNoNarrower}}, nil
+	}
+	if len(a.methods) == 0 {
+		return ParamResult{Reason: &Reason{Kind: ReasonNoMethods}}, nil
+	}
+
+	var aliases []*ast.Ident
+	for _, v := range a.aliases.Slice() {
+		aliases = append(aliases, a.defs[v])
+	}
+	sort.Slice(aliases, func(i, j int) bool { return aliases[i].Pos() < aliases[j].Pos() })
+
+	return ParamResult{Methods: a.methods, Uses: a.uses, Requires: a.requires, Aliases: aliases}, nil
+}
+
+// ssaAnalyzer is the EngineSSA counterpart of analyzer.
+// It follows the values of a parameter
+// (and of the local variables it is copied into)
+// through the SSA form of a function
+// and its closures.
+type ssaAnalyzer struct {
+	pkg    *packages.Package
+	fndecl *ast.FuncDecl
+	obj    types.Object
+
+	objmethods, methods MethodMap
+	uses                map[string][]Use
+	summaries           map[types.Object]paramSummary
+	requires            []ParamRef
+	reason              *Reason
+
+	// queue holds values of obj's type to be analyzed,
+	// and cells the addresses of local variables holding such values.
+	queue, cells []ssa.Value
+	seen         set.Of[ssa.Value]
+
+	// aliases is the set of local variables that hold obj's value
+	// (and so must change type along with it).
+	aliases set.Of[types.Object]
+
+	// refs maps each variable to the references to it in the function.
+	refs map[types.Object][]*ssa.DebugRef
+
+	// defs and specs map the variables declared in the function
+	// to their declaring identifiers and (for var declarations) their specs.
+	defs  map[types.Object]*ast.Ident
+	specs map[types.Object]*ast.ValueSpec
+
+	// explicit holds the positions (of the left parens) of calls
+	// to explicitly instantiated generic functions, like G[*os.File](f).
+	explicit set.Of[token.Pos]
+}
+
+// index populates a.refs from fn and its closures,
+// and a.defs, a.specs, and a.explicit from the function's syntax.
+func (a *ssaAnalyzer) index(fn *ssa.Function) {
+	var visit func(*ssa.Function)
+	visit = func(fn *ssa.Function) {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				if ref, ok := instr.(*ssa.DebugRef); ok {
+					if obj := ref.Object(); obj != nil {
+						a.refs[obj] = append(a.refs[obj], ref)
+					}
+				}
+			}
+		}
+		for _, anon := range fn.AnonFuncs {
+			visit(anon)
+		}
+	}
+	visit(fn)
+
+	ast.Inspect(a.fndecl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			if obj := a.pkg.TypesInfo.Defs[n]; obj != nil {
+				a.defs[obj] = n
+			}
+		case *ast.ValueSpec:
+			for _, name := range n.Names {
+				if obj := a.pkg.TypesInfo.Defs[name]; obj != nil {
+					a.specs[obj] = n
+				}
+			}
+		case *ast.CallExpr:
+			switch fun := ast.Unparen(n.Fun).(type) {
+			case *ast.IndexExpr, *ast.IndexListExpr:
+				if _, fn := funcRef(a.pkg.TypesInfo, fun); fn != nil {
+					a.explicit.Add(n.Lparen)
+				}
+			}
+		}
+		return true
+	})
+}
+
+func (a *ssaAnalyzer) run(param ssa.Value) bool {
+	a.addValue(param)
+	a.addVar(a.obj)
+
+	for len(a.queue) > 0 || len(a.cells) > 0 {
+		if n := len(a.queue); n > 0 {
+			v := a.queue[n-1]
+			a.queue = a.queue[:n-1]
+			if !a.value(v) {
+				return false
+			}
+			continue
+		}
+		n := len(a.cells)
+		cell := a.cells[n-1]
+		a.cells = a.cells[:n-1]
+		if !a.cell(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *ssaAnalyzer) addValue(v ssa.Value) {
+	if a.seen.Has(v) {
+		return
+	}
+	a.seen.Add(v)
+	a.queue = append(a.queue, v)
+}
+
+func (a *ssaAnalyzer) addCell(v ssa.Value) {
+	if a.seen.Has(v) {
+		return
+	}
+	a.seen.Add(v)
+	a.cells = append(a.cells, v)
+}
+
+// addVar adds all the values of the variable obj to the queue.
+func (a *ssaAnalyzer) addVar(obj types.Object) {
+	for _, ref := range a.refs[obj] {
+		switch {
+		case ref.IsAddr:
+			a.addCell(ref.X)
+		case types.Identical(ref.X.Type(), obj.Type()):
+			a.addValue(ref.X)
+		default:
+			// A reference to the variable in a context requiring an implicit conversion,
+			// e.g. an element of a composite literal.
+			// The value is the result of the conversion,
+			// which is handled as a use of the variable's own value.
+		}
+	}
+}
+
+// ref handles a reference (at p) to obj,
+// a variable holding one of our values.
+func (a *ssaAnalyzer) ref(obj types.Object, p interface{ Pos() token.Pos }) bool {
+	if obj == nil || obj == a.obj || a.aliases.Has(obj) {
+		return true
+	}
+	v, ok := obj.(*types.Var)
+	if !ok {
+		return true
+	}
+	if _, ok := a.defs[v]; !ok {
+		// Not a local variable.
+		return a.reject(p, ReasonConcreteAssign, "")
+	}
+	if !types.Identical(v.Type(), a.obj.Type()) {
+		return a.reject(p, ReasonConcreteAssign, "")
+	}
+	if spec, ok := a.specs[v]; ok && spec.Type != nil && len(spec.Names) > 1 {
+		return a.reject(p, ReasonUnsupported, "declaration of multiple variables")
+	}
+	if !canAlias(a.defs[v].Pos(), a.specs[v], p.Pos()) {
+		return a.reject(p, ReasonConcreteAssign, "")
+	}
+	a.aliases.Add(v)
+	a.addVar(v)
+	return true
+}
+
+// value handles the uses of v,
+// which has the type of our object.
+func (a *ssaAnalyzer) value(v ssa.Value) bool {
+	refs := v.Referrers()
+	if refs == nil {
+		return true
+	}
+	for _, instr := range *refs {
+		if !a.instr(v, instr) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *ssaAnalyzer) instr(v ssa.Value, instr ssa.Instruction) bool {
+	switch instr := instr.(type) {
+	case *ssa.DebugRef:
+		return a.ref(instr.Object(), instr)
+
+	case ssa.CallInstruction:
+		return a.call(v, instr.Common(), instr)
+
+	case *ssa.MakeInterface:
+		a.addMethods(instr.Type(), instr, "conversion to "+a.typeString(instr.Type()))
+		return true
+
+	case *ssa.ChangeInterface:
+		a.addMethods(instr.Type(), instr, "conversion to "+a.typeString(instr.Type()))
+		return true
+
+	case *ssa.TypeAssert:
+		return true
+
+	case *ssa.Phi:
+		a.addValue(instr)
+		return true
+
+	case *ssa.Store:
+		if instr.Addr == v {
+			return a.reject(instr, ReasonUnaryOp, "*")
+		}
+		if alloc, ok := instr.Addr.(*ssa.Alloc); ok {
+			// Storing into a local variable.
+			a.addCell(alloc)
+			return true
+		}
+		return a.reject(instr, ReasonConcreteAssign, "")
+
+	case *ssa.UnOp:
+		if instr.Op == token.MUL && a.derefForMethods(instr) {
+			return true
+		}
+		if instr.Op == token.ARROW {
+			return a.reject(instr, ReasonUnaryOp, "<-")
+		}
+		return a.reject(instr, ReasonUnaryOp, instr.Op.String())
+
+	case *ssa.BinOp:
+		switch instr.Op {
+		case token.EQL, token.NEQ:
+			other := instr.X
+			if other == v {
+				other = instr.Y
+			}
+			if a.seen.Has(other) {
+				return true
+			}
+			return a.reject(instr, ReasonConcreteComparison, "")
+		}
+		return a.reject(instr, ReasonArithmetic, instr.Op.String())
+
+	case *ssa.Return:
+		return a.reject(instr, ReasonConcreteReturn, "")
+
+	case *ssa.Send:
+		if instr.Chan == v {
+			return a.reject(instr, ReasonChannel, "")
+		}
+		return a.reject(instr, ReasonConcreteSend, "")
+
+	case *ssa.MakeClosure:
+		return a.closure(v, instr)
+
+	case *ssa.If:
+		return a.reject(instr, ReasonCondition, "")
+
+	case *ssa.MapUpdate:
+		return a.reject(instr, ReasonConcreteElement, "")
+
+	case *ssa.Field:
+		return a.reject(instr, ReasonFieldAccess, "."+fieldName(instr.X.Type(), instr.Field))
+
+	case *ssa.FieldAddr:
+		return a.reject(instr, ReasonFieldAccess, "."+fieldName(instr.X.Type(), instr.Field))
+
+	case *ssa.Select:
+		return a.reject(instr, ReasonChannel, "")
+
+	case *ssa.MakeSlice, *ssa.MakeChan, *ssa.MakeMap:
+		return a.reject(instr, ReasonConcreteParam, "make")
+
+	case *ssa.Index, *ssa.IndexAddr, *ssa.Lookup, *ssa.Slice:
+		return a.reject(instr, ReasonIndex, "")
+
+	case *ssa.Range:
+		return a.reject(instr, ReasonRange, "")
+
+	case *ssa.Convert, *ssa.ChangeType:
+		return a.reject(instr, ReasonConversion, a.typeString(instr.(ssa.Value).Type()))
+	}
+
+	return a.reject(instr, ReasonUnsupported, fmt.Sprintf("%T", instr))
+}
+
+// cell handles the uses of the address of a local variable
+// that holds one of our values.
+func (a *ssaAnalyzer) cell(cell ssa.Value) bool {
+	refs := cell.Referrers()
+	if refs == nil {
+		return true
+	}
+	for _, instr := range *refs {
+		switch instr := instr.(type) {
+		case *ssa.DebugRef:
+			if !a.ref(instr.Object(), instr) {
+				return false
+			}
+
+		case *ssa.UnOp:
+			if instr.Op != token.MUL {
+				return a.reject(instr, ReasonUnaryOp, instr.Op.String())
+			}
+			a.addValue(instr)
+
+		case *ssa.Store:
+			if instr.Addr != cell {
+				return a.reject(instr, ReasonAddress, a.typeString(cell.Type()))
+			}
+			// Storing a value into our variable is OK.
+			// Anything assignable to it is assignable to the new type.
+
+		case *ssa.MakeClosure:
+			for i, binding := range instr.Bindings {
+				if binding == cell {
+					a.addCell(instr.Fn.(*ssa.Function).FreeVars[i])
+				}
+			}
+
+		case *ssa.MakeInterface:
+			// The address of our variable is converted to an interface.
+			// Once the variable is decoupled,
+			// its address is a pointer to an interface,
+			// which has no methods,
+			// and which points to a different kind of value
+			// unless our object is already an interface.
+			if intf := getType[*types.Interface](instr.Type()); intf == nil || intf.NumMethods() > 0 || !types.IsInterface(a.obj.Type()) {
+				return a.reject(instr, ReasonAddress, a.typeString(instr.Type()))
+			}
+
+		default:
+			return a.reject(instr, ReasonAddress, a.typeString(cell.Type()))
+		}
+	}
+	return true
+}
+
+// derefForMethods tells whether the result of dereferencing one of our (pointer) values
+// is used only as the receiver of method calls,
+// as when calling a value method on a pointer.
+// If so, it adds those methods.
+func (a *ssaAnalyzer) derefForMethods(deref ssa.Value) bool {
+	refs := deref.Referrers()
+	if refs == nil {
+		return true
+	}
+	var methods []*types.Func
+	for _, instr := range *refs {
+		switch instr := instr.(type) {
+		case *ssa.DebugRef:
+			continue
+		case ssa.CallInstruction:
+			m := a.staticMethod(deref, instr.Common())
+			if m == nil {
+				return false
+			}
+			for _, arg := range instr.Common().Args[1:] {
+				if arg == deref {
+					return false
+				}
+			}
+			methods = append(methods, m)
+		default:
+			return false
+		}
+	}
+	for _, m := range methods {
+		a.addMethod(m.Name(), m.Type().(*types.Signature), deref, "")
+	}
+	return true
+}
+
+// staticMethod returns the method called by common
+// if it is a static call to a method with v as the receiver.
+func (a *ssaAnalyzer) staticMethod(v ssa.Value, common *ssa.CallCommon) *types.Func {
+	callee := common.StaticCallee()
+	if callee == nil || callee.Signature.Recv() == nil || len(common.Args) == 0 || common.Args[0] != v {
+		return nil
+	}
+	m, _ := callee.Object().(*types.Func)
+	return m
+}
+
+func (a *ssaAnalyzer) call(v ssa.Value, common *ssa.CallCommon, instr interface{ Pos() token.Pos }) bool {
+	if common.IsInvoke() {
+		if common.Value == v {
+			a.addMethod(common.Method.Name(), common.Method.Type().(*types.Signature), instr, "")
+		}
+		for i, arg := range common.Args {
+			if arg == v && !a.arg(common, i, common.Signature().Params().At(i).Type(), instr) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if common.Value == v {
+		return a.reject(instr, ReasonCall, "")
+	}
+
+	if b, ok := common.Value.(*ssa.Builtin); ok {
+		return a.reject(instr, ReasonConcreteParam, b.Name())
+	}
+
+	var (
+		sig    = common.Signature()
+		params = sig.Params()
+		offset int
+	)
+	if m := a.staticMethod(v, common); m != nil {
+		a.addMethod(m.Name(), m.Type().(*types.Signature), instr, "")
+	}
+	if sig.Recv() != nil {
+		// The receiver is Args[0].
+		offset = 1
+	}
+	for i, arg := range common.Args {
+		if arg != v || i < offset {
+			continue
+		}
+		j := i - offset
+		if j >= params.Len() {
+			panic(errf("cannot send %d argument(s) to %d-parameter function at %s", i+1, params.Len(), a.pos(instr)))
+		}
+		if !a.arg(common, j, params.At(j).Type(), instr) {
+			return false
+		}
+	}
+	return true
+}
+
+// arg handles one of our values passed as argument j
+// (with type ptype)
+// in a call.
+func (a *ssaAnalyzer) arg(common *ssa.CallCommon, j int, ptype types.Type, instr interface{ Pos() token.Pos }) bool {
+	desc := a.calleeString(common)
+	if intf := getType[*types.Interface](ptype); intf != nil {
+		a.addMethods(ptype, instr, desc)
+		return true
+	}
+	if callee := common.StaticCallee(); callee != nil {
+		if fn, ok := callee.Object().(*types.Func); ok {
+			if constraint := typeParamConstraint(fn.Origin().Type(), j); constraint != nil && !a.explicit.Has(common.Pos()) {
+				// The callee is generic,
+				// and our value can be passed to it as any type satisfying the constraint.
+				a.addMethods(constraint, instr, desc)
+				return true
+			}
+			params := fn.Origin().Type().(*types.Signature).Params()
+			if j < params.Len() {
+				if summary, ok := a.summaries[params.At(j)]; ok {
+					for name, sig := range summary.res.Methods {
+						a.methods[name] = sig
+						a.addUse(name, instr, desc)
+					}
+					a.requires = append(a.requires, summary.ref)
+					return true
+				}
+			}
+		}
+	}
+	return a.reject(instr, ReasonConcreteParam, desc)
+}
+
+// closure handles one of our values bound into a closure.
+// This is either a method value (a "bound method" closure)
+// or a captured variable that is never reassigned
+// (which SSA may pass by value).
+func (a *ssaAnalyzer) closure(v ssa.Value, mc *ssa.MakeClosure) bool {
+	fn := mc.Fn.(*ssa.Function)
+	if strings.HasSuffix(fn.Name(), "$bound") {
+		if m, ok := fn.Object().(*types.Func); ok {
+			a.addMethod(m.Name(), m.Type().(*types.Signature), mc, "")
+			return true
+		}
+	}
+	for i, binding := range mc.Bindings {
+		if binding == v {
+			a.addValue(fn.FreeVars[i])
+		}
+	}
+	return true
+}
+
+func (a *ssaAnalyzer) addMethods(typ types.Type, p interface{ Pos() token.Pos }, via string) {
+	intf := getType[*types.Interface](typ)
+	if intf == nil {
+		return
+	}
+	for i := 0; i < intf.NumMethods(); i++ {
+		m := intf.Method(i)
+		a.addMethod(m.Name(), m.Type().(*types.Signature), p, via)
+	}
+}
+
+func (a *ssaAnalyzer) addMethod(name string, sig *types.Signature, p interface{ Pos() token.Pos }, via string) {
+	a.methods[name] = sig
+	a.addUse(name, p, via)
+}
+
+func (a *ssaAnalyzer) addUse(method string, p interface{ Pos() token.Pos }, via string) {
+	a.uses[method] = append(a.uses[method], Use{Pos: a.pos(p), Via: via})
+}
+
+func (a *ssaAnalyzer) calleeString(common *ssa.CallCommon) string {
+	if common.IsInvoke() {
+		return common.Method.Name()
+	}
+	if callee := common.StaticCallee(); callee != nil {
+		if fn, ok := callee.Object().(*types.Func); ok {
+			if fn.Pkg() != nil && fn.Pkg() != a.pkg.Types && fn.Type().(*types.Signature).Recv() == nil {
+				return fn.Pkg().Name() + "." + fn.Name()
+			}
+			return fn.Name()
+		}
+	}
+	return common.Value.Name()
+}
+
+// fieldName returns the name of field i of typ,
+// which is a struct type or a pointer to one.
+func fieldName(typ types.Type, i int) string {
+	if ptr := getType[*types.Pointer](typ); ptr != nil {
+		typ = ptr.Elem()
+	}
+	if st := getType[*types.Struct](typ); st != nil && i < st.NumFields() {
+		return st.Field(i).Name()
+	}
+	return fmt.Sprintf("#%d", i)
+}
+
+func (a *ssaAnalyzer) typeString(typ types.Type) string {
+	return types.TypeString(typ, types.RelativeTo(a.pkg.Types))
+}
+
+// pos produces the position of p,
+// or of the function when p has none
+// (as for some synthesized instructions).
+func (a *ssaAnalyzer) pos(p interface{ Pos() token.Pos }) token.Position {
+	if pos := p.Pos(); pos.IsValid() {
+		return a.pkg.Fset.Position(pos)
+	}
+	return a.pkg.Fset.Position(a.fndecl.Pos())
+}
+
+func (a *ssaAnalyzer) reject(p interface{ Pos() token.Pos }, kind ReasonKind, detail string) bool {
+	if a.reason == nil {
+		a.reason = &Reason{Kind: kind, Pos: a.pos(p), Detail: detail}
+	}
+	return false
+}
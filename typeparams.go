@@ -0,0 +1,134 @@
+package decouple
+
+import (
+	"go/ast"
+	"go/types"
+
+	"github.com/bobg/errors"
+	"golang.org/x/tools/go/packages"
+)
+
+// CheckTypeParams checks the type parameters of a generic function declaration,
+// which should appear in the given package,
+// which should be one of the packages contained in the Checker.
+// The result maps the names of type parameters
+// whose constraints require more methods than the function uses
+// to MethodMaps of the methods it does use.
+// An empty MethodMap means no methods are used,
+// so the constraint could be any.
+//
+// A type parameter is considered only when its constraint is a set of methods
+// (no type terms, as in ~int | ~string),
+// the function is not a method,
+// and the type parameter appears in the function's signature and body
+// only as the type of some of its parameters.
+// Those parameters are analyzed as in CheckParam.
+func (ch Checker) CheckTypeParams(pkg *packages.Package, fndecl *ast.FuncDecl) (_ map[string]MethodMap, err error) {
+	defer recoverDerr(&err)
+
+	if fndecl.Recv != nil || fndecl.Type.TypeParams == nil || fndecl.Body == nil {
+		return nil, nil
+	}
+	fnobj, ok := pkg.TypesInfo.Defs[fndecl.Name].(*types.Func)
+	if !ok {
+		return nil, nil
+	}
+	tparams := fnobj.Type().(*types.Signature).TypeParams()
+
+	var result map[string]MethodMap
+	for i := 0; i < tparams.Len(); i++ {
+		name := tparams.At(i).Obj().Name()
+		mm, err := ch.checkTypeParam(pkg, fndecl, i)
+		if err != nil {
+			return nil, errors.Wrapf(err, "analyzing type parameter %s of %s", name, fndecl.Name.Name)
+		}
+		if mm == nil {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]MethodMap)
+		}
+		result[name] = mm
+	}
+	return result, nil
+}
+
+// checkTypeParam checks type parameter i of fndecl.
+// It returns nil if its constraint cannot be narrowed.
+func (ch Checker) checkTypeParam(pkg *packages.Package, fndecl *ast.FuncDecl, i int) (MethodMap, error) {
+	var (
+		info    = pkg.TypesInfo
+		sig     = info.Defs[fndecl.Name].Type().(*types.Signature)
+		tparams = sig.TypeParams()
+		tp      = tparams.At(i)
+	)
+
+	constraint := getType[*types.Interface](tp.Constraint())
+	if constraint == nil || !constraint.IsMethodSet() || constraint.NumMethods() == 0 {
+		return nil, nil
+	}
+	for j := 0; j < constraint.NumMethods(); j++ {
+		if mentions(constraint.Method(j).Type(), tp) {
+			// A self-referential constraint, like interface{ Less(T) bool }.
+			return nil, nil
+		}
+	}
+	for j := 0; j < tparams.Len(); j++ {
+		if j != i && mentions(tparams.At(j).Constraint(), tp) {
+			return nil, nil
+		}
+	}
+	if mentions(sig.Results(), tp) {
+		return nil, nil
+	}
+
+	var names []*ast.Ident
+	for _, field := range fndecl.Type.Params.List {
+		typ := info.TypeOf(field.Type)
+		if typ == tp {
+			names = append(names, field.Names...)
+			continue
+		}
+		if mentions(typ, tp) {
+			return nil, nil
+		}
+	}
+
+	var mentioned bool
+	ast.Inspect(fndecl.Body, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && info.Uses[id] == tp.Obj() {
+			mentioned = true
+		}
+		return !mentioned
+	})
+	if mentioned {
+		// The body refers to the type parameter directly,
+		// e.g. in a conversion or a variable declaration.
+		return nil, nil
+	}
+
+	mm := make(MethodMap)
+	for _, name := range names {
+		if name.Name == "_" {
+			continue
+		}
+		res, err := ch.checkParamEngine(pkg, fndecl, name, info.Defs[name])
+		if err != nil {
+			return nil, errors.Wrapf(err, "analyzing parameter %s", name.Name)
+		}
+		if res.Reason != nil {
+			if res.Reason.Kind == ReasonNoMethods {
+				continue
+			}
+			return nil, nil
+		}
+		for method, msig := range res.Methods {
+			mm[method] = msig
+		}
+	}
+
+	if len(mm) >= constraint.NumMethods() {
+		return nil, nil
+	}
+	return mm, nil
+}
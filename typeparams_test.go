@@ -0,0 +1,61 @@
+package decouple
+
+import (
+	"testing"
+
+	"github.com/bobg/go-generics/v3/maps"
+)
+
+func TestCheckTypeParams(t *testing.T) {
+	checker, err := NewCheckerFromDir("_testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tuples, err := checker.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		fn, tparam string
+		want       []string // nil for no suggestion
+	}{
+		{fn: "readCloseGeneric", tparam: "RC", want: []string{"Read"}},
+		{fn: "closeGeneric", tparam: "C", want: []string{"Close"}},
+		{fn: "countGeneric", tparam: "S", want: []string{}},
+		{fn: "stringGeneric", tparam: "S"},
+		{fn: "readAllGeneric", tparam: "R"},
+		{fn: "pickGeneric", tparam: "R"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.fn, func(t *testing.T) {
+			for _, tuple := range tuples {
+				if tuple.F.Name.Name != tc.fn {
+					continue
+				}
+				mm, ok := tuple.T[tc.tparam]
+				if tc.want == nil {
+					if ok {
+						t.Errorf("got %v, want no suggestion", maps.Keys(mm))
+					}
+					return
+				}
+				if !ok {
+					t.Fatal("got no suggestion")
+				}
+				if len(mm) != len(tc.want) {
+					t.Fatalf("got %v, want %v", maps.Keys(mm), tc.want)
+				}
+				for _, m := range tc.want {
+					if _, ok := mm[m]; !ok {
+						t.Errorf("got %v, want %v", maps.Keys(mm), tc.want)
+					}
+				}
+				return
+			}
+			t.Fatalf("function %s not found", tc.fn)
+		})
+	}
+}
@@ -8,6 +8,8 @@ func getType[T types.Type](typ types.Type) T {
 		return typ
 	case *types.Named:
 		return getType[T](typ.Underlying())
+	case *types.Alias:
+		return getType[T](types.Unalias(typ))
 	default:
 		return zero[T]()
 	}
@@ -17,3 +19,123 @@ func getType[T types.Type](typ types.Type) T {
 func zero[T any]() (res T) {
 	return
 }
+
+// typeParamConstraint tells whether a concrete argument
+// passed as parameter i of a generic function with type fntype
+// (the uninstantiated signature)
+// could instead be an interface value,
+// with the type parameter inferred as that interface type.
+// That requires the parameter's type to be one of the function's own type parameters,
+// mentioned nowhere else in its signature or its other constraints,
+// and constrained only by methods that do not mention it either.
+// If so, it returns the constraint,
+// whose methods are the ones the argument needs;
+// otherwise nil.
+func typeParamConstraint(fntype types.Type, i int) *types.Interface {
+	sig, ok := fntype.(*types.Signature)
+	if !ok {
+		return nil
+	}
+	params := sig.Params()
+	if i >= params.Len() || (sig.Variadic() && i == params.Len()-1) {
+		return nil
+	}
+	tp, ok := params.At(i).Type().(*types.TypeParam)
+	if !ok {
+		return nil
+	}
+
+	var found bool
+	for j := 0; j < sig.TypeParams().Len(); j++ {
+		other := sig.TypeParams().At(j)
+		if other == tp {
+			found = true
+		} else if mentions(other.Constraint(), tp) {
+			return nil
+		}
+	}
+	if !found {
+		// A type parameter of the receiver, fixed by its type.
+		return nil
+	}
+
+	for j := 0; j < params.Len(); j++ {
+		if j != i && mentions(params.At(j).Type(), tp) {
+			return nil
+		}
+	}
+	if mentions(sig.Results(), tp) {
+		return nil
+	}
+
+	constraint := getType[*types.Interface](tp.Constraint())
+	if constraint == nil || !constraint.IsMethodSet() {
+		// E.g. comparable, or a union of types.
+		return nil
+	}
+	for j := 0; j < constraint.NumMethods(); j++ {
+		if mentions(constraint.Method(j).Type(), tp) {
+			return nil
+		}
+	}
+	return constraint
+}
+
+// mentions tells whether typ refers to the type parameter tp.
+func mentions(typ types.Type, tp *types.TypeParam) bool {
+	switch typ := typ.(type) {
+	case *types.TypeParam:
+		return typ == tp
+	case *types.Pointer:
+		return mentions(typ.Elem(), tp)
+	case *types.Slice:
+		return mentions(typ.Elem(), tp)
+	case *types.Array:
+		return mentions(typ.Elem(), tp)
+	case *types.Chan:
+		return mentions(typ.Elem(), tp)
+	case *types.Map:
+		return mentions(typ.Key(), tp) || mentions(typ.Elem(), tp)
+	case *types.Signature:
+		return mentions(typ.Params(), tp) || mentions(typ.Results(), tp)
+	case *types.Tuple:
+		for i := 0; i < typ.Len(); i++ {
+			if mentions(typ.At(i).Type(), tp) {
+				return true
+			}
+		}
+	case *types.Struct:
+		for i := 0; i < typ.NumFields(); i++ {
+			if mentions(typ.Field(i).Type(), tp) {
+				return true
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < typ.NumEmbeddeds(); i++ {
+			if mentions(typ.EmbeddedType(i), tp) {
+				return true
+			}
+		}
+		for i := 0; i < typ.NumExplicitMethods(); i++ {
+			if mentions(typ.ExplicitMethod(i).Type(), tp) {
+				return true
+			}
+		}
+	case *types.Union:
+		for i := 0; i < typ.Len(); i++ {
+			if mentions(typ.Term(i).Type(), tp) {
+				return true
+			}
+		}
+	case *types.Named:
+		args := typ.TypeArgs()
+		for i := 0; i < args.Len(); i++ {
+			if mentions(args.At(i), tp) {
+				return true
+			}
+		}
+	case *types.Alias:
+		return mentions(types.Unalias(typ), tp)
+	}
+	return false
+}
@@ -0,0 +1,874 @@
+package decouple
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bobg/go-generics/v3/set"
+	"golang.org/x/tools/go/packages"
+)
+
+// TypeSet is a constraint with a type set,
+// such as cmp.Ordered or ~float32 | ~float64,
+// for a new type parameter
+// that some parameters of a basic type (like int or float64) could have instead,
+// because they are used only with operators and conversions
+// that every type in the set supports.
+// See Checker.CheckTypeSets.
+type TypeSet struct {
+	// Kind is the kind of type set.
+	Kind TypeSetKind
+
+	// Params are the names of the parameters that would have the new type parameter as their type,
+	// in order.
+	// Their values are combined
+	// (as in a+b, or by being returned as the same result),
+	// so they must all have the same type.
+	Params []string
+
+	// Results are the indexes of the function's results
+	// that would have the type parameter as their type too,
+	// because the parameters' values are returned in them.
+	Results []int
+
+	// Vars are the type expressions in declarations of local variables
+	// (as in "var sum float64")
+	// that hold the parameters' values,
+	// and that would have the type parameter too.
+	Vars []ast.Expr
+
+	cmp bool // whether the package cmp is available, which requires Go 1.21
+}
+
+// TypeSetKind is the kind of a TypeSet.
+type TypeSetKind int
+
+const (
+	// TypeSetComparable is the constraint comparable,
+	// for values that are only compared with == and !=.
+	TypeSetComparable TypeSetKind = iota
+
+	// TypeSetOrdered is the set of integer, floating-point, and string types
+	// (cmp.Ordered),
+	// for values that are also compared with <, <=, >, and >=,
+	// or added with +.
+	TypeSetOrdered
+
+	// TypeSetNumber is the set of integer and floating-point types,
+	// for values that are also used in other arithmetic,
+	// combined with constants,
+	// or converted to other numeric types.
+	TypeSetNumber
+
+	// TypeSetInteger is the set of integer types,
+	// for values that are also used with %, bitwise operators, and shifts,
+	// or as indexes.
+	TypeSetInteger
+
+	// TypeSetFloat is the set of floating-point types,
+	// for values that are combined with constants
+	// that not every integer type can represent.
+	TypeSetFloat
+)
+
+var (
+	cmpPkg = types.NewPackage("cmp", "cmp")
+
+	integerTerms = []string{"~int", "~int8", "~int16", "~int32", "~int64", "~uint", "~uint8", "~uint16", "~uint32", "~uint64", "~uintptr"}
+	floatTerms   = []string{"~float32", "~float64"}
+)
+
+// Constraint produces the text of the constraint,
+// e.g. "cmp.Ordered" or "~float32 | ~float64",
+// using q (which may be nil) to qualify package names as in types.TypeString.
+// When the cmp package is not available,
+// the terms of cmp.Ordered are written out.
+func (ts TypeSet) Constraint(q types.Qualifier) string {
+	var terms []string
+	switch ts.Kind {
+	case TypeSetComparable:
+		return "comparable"
+	case TypeSetOrdered:
+		if ts.cmp {
+			name := "cmp"
+			if q != nil {
+				name = q(cmpPkg)
+			}
+			return name + ".Ordered"
+		}
+		terms = slices.Concat(integerTerms, floatTerms, []string{"~string"})
+	case TypeSetNumber:
+		terms = slices.Concat(integerTerms, floatTerms)
+	case TypeSetInteger:
+		terms = integerTerms
+	case TypeSetFloat:
+		terms = floatTerms
+	}
+	return strings.Join(terms, " | ")
+}
+
+// typeSetOps is a set of the kinds of operations
+// that a TypeSetKind supports.
+type typeSetOps int
+
+const (
+	opsEqual   typeSetOps = 1 << iota // == and !=
+	opsOrdered                        // <, <=, >, >=, and +
+	opsNumeric                        // other arithmetic, ++ and --, and numeric conversions
+	opsInteger                        // %, bitwise operators, shifts, and indexing
+)
+
+func (k TypeSetKind) ops() typeSetOps {
+	switch k {
+	case TypeSetComparable:
+		return opsEqual
+	case TypeSetOrdered:
+		return opsEqual | opsOrdered
+	case TypeSetNumber, TypeSetFloat:
+		return opsEqual | opsOrdered | opsNumeric
+	}
+	return opsEqual | opsOrdered | opsNumeric | opsInteger
+}
+
+// contains tells whether the basic type with the given info is in the type set.
+func (k TypeSetKind) contains(info types.BasicInfo) bool {
+	switch k {
+	case TypeSetComparable:
+		return true
+	case TypeSetOrdered:
+		return info&types.IsOrdered != 0
+	case TypeSetNumber:
+		return info&(types.IsInteger|types.IsFloat) != 0
+	case TypeSetInteger:
+		return info&types.IsInteger != 0
+	}
+	return info&types.IsFloat != 0
+}
+
+// represents tells whether every type in the set
+// can represent each of the untyped constants in consts.
+func (k TypeSetKind) represents(consts []constant.Value) bool {
+	switch k {
+	case TypeSetComparable, TypeSetOrdered:
+		// Strings and numbers have no constants in common.
+		return len(consts) == 0
+
+	case TypeSetNumber, TypeSetInteger:
+		// Every integer type, including int8 and uint8,
+		// can represent 0 through 127.
+		for _, c := range consts {
+			c = constant.ToInt(c)
+			if c.Kind() != constant.Int {
+				return false
+			}
+			if n, exact := constant.Int64Val(c); !exact || n < 0 || n > math.MaxInt8 {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, c := range consts {
+		c = constant.ToFloat(c)
+		if c.Kind() != constant.Float {
+			return false
+		}
+		if f, _ := constant.Float64Val(c); math.IsInf(f, 0) || math.Abs(f) > math.MaxFloat32 {
+			return false
+		}
+	}
+	return true
+}
+
+// chooseTypeSet chooses the widest type set
+// that contains the basic type with the given info,
+// supports ops,
+// and can represent consts.
+func chooseTypeSet(info types.BasicInfo, ops typeSetOps, consts []constant.Value) (TypeSetKind, bool) {
+	for _, k := range []TypeSetKind{TypeSetComparable, TypeSetOrdered, TypeSetNumber, TypeSetInteger, TypeSetFloat} {
+		if k.contains(info) && ops&^k.ops() == 0 && k.represents(consts) {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+// CheckTypeSets checks the parameters of basic types
+// (like int, float64, and string)
+// in a function declaration,
+// which should appear in the given package,
+// which should be one of the packages contained in the Checker.
+// The result lists the groups of those parameters
+// that could have the type of a new type parameter
+// constrained by a type set,
+// because they are used only with operators and conversions
+// that every type in the set supports,
+// as when func Abs(x float64) float64
+// could be func Abs[T ~int | ... | ~float64](x T) T.
+//
+// A parameter's value may flow to other parameters, local variables, and results
+// (which then get the type parameter too),
+// or to values of type any,
+// but not elsewhere.
+// Untyped constants combined with the parameters
+// must be representable by every type in the set.
+// Methods, functions used as values,
+// and parameters of named types
+// are not considered.
+func (ch Checker) CheckTypeSets(pkg *packages.Package, fndecl *ast.FuncDecl) []TypeSet {
+	if fndecl.Recv != nil || fndecl.Body == nil {
+		return nil
+	}
+	if ch.fixedSignature(pkg, fndecl) != nil {
+		return nil
+	}
+	fn, ok := pkg.TypesInfo.Defs[fndecl.Name].(*types.Func)
+	if !ok {
+		return nil
+	}
+
+	f := &typeSetFinder{
+		info:    pkg.TypesInfo,
+		sig:     fn.Type().(*types.Signature),
+		nodes:   set.New[types.Object](),
+		parent:  make(map[types.Object]types.Object),
+		tainted: set.New[types.Object](),
+		ops:     make(map[types.Object]typeSetOps),
+		consts:  make(map[types.Object][]constant.Value),
+		decls:   make(map[types.Object]ast.Expr),
+		handled: set.New[*ast.Ident](),
+	}
+
+	var params []*ast.Ident
+	for _, field := range fndecl.Type.Params.List {
+		for _, name := range field.Names {
+			if obj := f.info.Defs[name]; name.Name != "_" && basicType(obj.Type()) != nil {
+				f.nodes.Add(obj)
+				params = append(params, name)
+			}
+		}
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	if results := fndecl.Type.Results; results != nil && (len(results.List) == 0 || len(results.List[0].Names) == 0) {
+		// Only unnamed results can be nodes.
+		// Named results are variables that would need their own analysis.
+		for i := 0; i < f.sig.Results().Len(); i++ {
+			if rv := f.sig.Results().At(i); basicType(rv.Type()) != nil {
+				f.nodes.Add(rv)
+			}
+		}
+	}
+	ast.Inspect(fndecl.Body, func(n ast.Node) bool {
+		var names []*ast.Ident
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE {
+				return true
+			}
+			for _, lhs := range n.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok {
+					names = append(names, id)
+				}
+			}
+		case *ast.ValueSpec:
+			names = n.Names
+		}
+		for _, name := range names {
+			if obj, ok := f.info.Defs[name].(*types.Var); ok && basicType(obj.Type()) != nil {
+				f.nodes.Add(obj)
+			}
+		}
+		return true
+	})
+
+	f.results = f.sig.Results()
+	f.walk(fndecl.Body)
+
+	// Any other use of a node makes its value flow somewhere it cannot.
+	for id, obj := range f.info.Uses {
+		if f.nodes.Has(obj) && fndecl.Body.Pos() <= id.Pos() && id.Pos() < fndecl.Body.End() && !f.handled.Has(id) {
+			f.tainted.Add(obj)
+		}
+	}
+
+	var (
+		cmpOK  = goVersionAtLeast(pkg, fndecl.Pos(), "go1.21")
+		result []TypeSet
+		seen   = set.New[types.Object]()
+	)
+	for _, param := range params {
+		root := f.find(f.info.Defs[param])
+		if seen.Has(root) {
+			continue
+		}
+		seen.Add(root)
+
+		var (
+			b       = basicType(root.Type())
+			ops     typeSetOps
+			consts  []constant.Value
+			members []types.Object
+			bad     bool
+		)
+		for obj := range f.nodes {
+			if f.find(obj) != root {
+				continue
+			}
+			members = append(members, obj)
+			if f.tainted.Has(obj) || !types.Identical(basicType(obj.Type()), b) {
+				bad = true
+			}
+			ops |= f.ops[obj]
+			consts = append(consts, f.consts[obj]...)
+		}
+		if bad || ops == 0 {
+			continue
+		}
+		kind, ok := chooseTypeSet(b.Info(), ops, consts)
+		if !ok {
+			continue
+		}
+
+		ts := TypeSet{Kind: kind, cmp: cmpOK}
+		for _, p := range params {
+			if f.find(f.info.Defs[p]) == root {
+				ts.Params = append(ts.Params, p.Name)
+			}
+		}
+		for i := 0; i < f.sig.Results().Len(); i++ {
+			if rv := f.sig.Results().At(i); f.nodes.Has(rv) && f.find(rv) == root {
+				ts.Results = append(ts.Results, i)
+			}
+		}
+		for _, obj := range members {
+			if expr, ok := f.decls[obj]; ok {
+				ts.Vars = append(ts.Vars, expr)
+			}
+		}
+		slices.SortFunc(ts.Vars, func(a, b ast.Expr) int { return int(a.Pos() - b.Pos()) })
+		result = append(result, ts)
+	}
+	return result
+}
+
+// basicType returns typ if it is a basic type other than bool
+// (possibly via an alias),
+// otherwise nil.
+func basicType(typ types.Type) *types.Basic {
+	b, ok := types.Unalias(typ).(*types.Basic)
+	if !ok || b.Info()&(types.IsInteger|types.IsFloat|types.IsComplex|types.IsString) == 0 || b.Info()&types.IsUntyped != 0 {
+		return nil
+	}
+	return b
+}
+
+// typeSetFinder finds the values in a function
+// that could have the type of a new type parameter.
+// The candidates ("nodes") are the function's parameters and unnamed results of basic types,
+// and the local variables of basic types.
+// Nodes whose values are combined
+// must have the same type,
+// so they are grouped with a union-find structure.
+type typeSetFinder struct {
+	info    *types.Info
+	sig     *types.Signature // of the function being checked
+	results *types.Tuple     // of the function or function literal being walked
+
+	nodes   set.Of[types.Object]
+	parent  map[types.Object]types.Object
+	tainted set.Of[types.Object] // nodes whose values flow where a type parameter cannot
+
+	ops    map[types.Object]typeSetOps
+	consts map[types.Object][]constant.Value // untyped constants combined with each node
+
+	decls map[types.Object]ast.Expr // the explicit types of local-variable nodes
+
+	handled set.Of[*ast.Ident] // the uses of nodes accounted for
+}
+
+func (f *typeSetFinder) find(obj types.Object) types.Object {
+	for {
+		p, ok := f.parent[obj]
+		if !ok {
+			return obj
+		}
+		obj = p
+	}
+}
+
+func (f *typeSetFinder) union(a, b types.Object) {
+	if a, b = f.find(a), f.find(b); a != b {
+		f.parent[b] = a
+	}
+}
+
+// term describes an expression of basic type.
+type term struct {
+	ids     []*ast.Ident   // the uses of nodes whose values flow into the expression
+	val     constant.Value // set for an untyped constant
+	foreign bool           // the expression has a value of some other origin
+}
+
+func (f *typeSetFinder) term(expr ast.Expr) term {
+	if isUntypedConst(f.info, expr) {
+		return term{val: f.info.Types[expr].Value}
+	}
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if f.nodes.Has(f.info.Uses[expr]) {
+			return term{ids: []*ast.Ident{expr}}
+		}
+	case *ast.ParenExpr:
+		return f.term(expr.X)
+	case *ast.UnaryExpr:
+		switch expr.Op {
+		case token.ADD, token.SUB, token.XOR:
+			return f.term(expr.X)
+		}
+	case *ast.BinaryExpr:
+		switch expr.Op {
+		case token.SHL, token.SHR:
+			if x := f.term(expr.X); x.val == nil {
+				return x
+			}
+		case token.ADD, token.SUB, token.MUL, token.QUO, token.REM, token.AND, token.OR, token.XOR, token.AND_NOT:
+			x, y := f.term(expr.X), f.term(expr.Y)
+			return term{ids: slices.Concat(x.ids, y.ids), foreign: x.foreign || y.foreign}
+		}
+	}
+	return term{foreign: true}
+}
+
+// isUntypedConst tells whether expr is an untyped constant expression.
+// (The types.Info records such an expression with the type it is converted to,
+// so this looks at the constants it refers to instead.)
+func isUntypedConst(info *types.Info, expr ast.Expr) bool {
+	if info.Types[expr].Value == nil {
+		return false
+	}
+	ok := true
+	ast.Inspect(expr, func(n ast.Node) bool {
+		var id *ast.Ident
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			ok = false
+		case *ast.SelectorExpr:
+			id = n.Sel
+		case *ast.Ident:
+			id = n
+		default:
+			return ok
+		}
+		if id != nil {
+			c, isConst := info.Uses[id].(*types.Const)
+			if !isConst {
+				ok = false
+			} else if b, isBasic := c.Type().(*types.Basic); !isBasic || b.Info()&types.IsUntyped == 0 {
+				ok = false
+			}
+		}
+		return false
+	})
+	return ok
+}
+
+// require records that the nodes in t need the given operations.
+func (f *typeSetFinder) require(t term, ops typeSetOps) {
+	for _, id := range t.ids {
+		f.ops[f.info.Uses[id]] |= ops
+	}
+}
+
+// use records that the uses of nodes in t are accounted for.
+func (f *typeSetFinder) use(t term) {
+	for _, id := range t.ids {
+		f.handled.Add(id)
+	}
+}
+
+// taint records that the values of the nodes in t
+// flow somewhere that a type parameter cannot.
+func (f *typeSetFinder) taint(t term) {
+	for _, id := range t.ids {
+		f.tainted.Add(f.info.Uses[id])
+	}
+}
+
+// combine records that the values in t,
+// which may include a constant,
+// are combined with the node obj,
+// so they must have the same type.
+func (f *typeSetFinder) combine(obj types.Object, t term) {
+	if t.foreign {
+		f.tainted.Add(obj)
+		f.taint(t)
+		return
+	}
+	for _, id := range t.ids {
+		f.union(obj, f.info.Uses[id])
+	}
+	if t.val != nil {
+		f.consts[obj] = append(f.consts[obj], t.val)
+	}
+}
+
+// sink records that the values in t flow to a value of type dst
+// that is not a node.
+func (f *typeSetFinder) sink(t term, dst types.Type) {
+	if _, ok := types.Unalias(dst).(*types.TypeParam); !ok {
+		if intf, ok := dst.Underlying().(*types.Interface); ok && intf.Empty() {
+			f.use(t)
+			return
+		}
+	}
+	f.taint(t)
+}
+
+// assign records the flow of the values in t to the variable obj,
+// which is declared here if decl is true.
+// Explicit is true if the variable's type is written out.
+func (f *typeSetFinder) assign(obj types.Object, t term, decl, explicit bool) {
+	switch {
+	case !f.nodes.Has(obj):
+		f.sink(t, obj.Type())
+	case t.val != nil && decl && !explicit:
+		// The variable has the default type of the constant.
+		f.tainted.Add(obj)
+	default:
+		f.combine(obj, t)
+		f.use(t)
+	}
+}
+
+var binaryOps = map[token.Token]typeSetOps{
+	token.EQL:     opsEqual,
+	token.NEQ:     opsEqual,
+	token.LSS:     opsOrdered,
+	token.LEQ:     opsOrdered,
+	token.GTR:     opsOrdered,
+	token.GEQ:     opsOrdered,
+	token.ADD:     opsOrdered,
+	token.SUB:     opsNumeric,
+	token.MUL:     opsNumeric,
+	token.QUO:     opsNumeric,
+	token.REM:     opsInteger,
+	token.AND:     opsInteger,
+	token.OR:      opsInteger,
+	token.XOR:     opsInteger,
+	token.AND_NOT: opsInteger,
+}
+
+var assignOps = map[token.Token]token.Token{
+	token.ADD_ASSIGN:     token.ADD,
+	token.SUB_ASSIGN:     token.SUB,
+	token.MUL_ASSIGN:     token.MUL,
+	token.QUO_ASSIGN:     token.QUO,
+	token.REM_ASSIGN:     token.REM,
+	token.AND_ASSIGN:     token.AND,
+	token.OR_ASSIGN:      token.OR,
+	token.XOR_ASSIGN:     token.XOR,
+	token.AND_NOT_ASSIGN: token.AND_NOT,
+	token.SHL_ASSIGN:     token.SHL,
+	token.SHR_ASSIGN:     token.SHR,
+}
+
+// walk records the operations on nodes in body.
+func (f *typeSetFinder) walk(body ast.Node) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			if litSig, ok := f.info.TypeOf(n).(*types.Signature); ok {
+				outer := f.results
+				f.results = litSig.Results()
+				f.walk(n.Body)
+				f.results = outer
+			}
+			return false
+
+		case *ast.BinaryExpr:
+			f.binary(n.Op, f.term(n.X), f.term(n.Y))
+
+		case *ast.UnaryExpr:
+			switch n.Op {
+			case token.ADD, token.SUB:
+				f.require(f.term(n.X), opsNumeric)
+			case token.XOR:
+				f.require(f.term(n.X), opsInteger)
+			}
+
+		case *ast.IncDecStmt:
+			x := f.term(n.X)
+			f.require(x, opsNumeric)
+			f.use(x)
+
+		case *ast.AssignStmt:
+			f.assignStmt(n)
+
+		case *ast.ValueSpec:
+			f.valueSpec(n)
+
+		case *ast.ReturnStmt:
+			f.returnStmt(n)
+
+		case *ast.CallExpr:
+			f.call(n)
+
+		case *ast.IndexExpr:
+			f.index(f.info.TypeOf(n.X), f.term(n.Index))
+
+		case *ast.SliceExpr:
+			for _, expr := range []ast.Expr{n.Low, n.High, n.Max} {
+				if expr != nil {
+					idx := f.term(expr)
+					f.require(idx, opsInteger)
+					f.use(idx)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// binary records the binary operation x op y.
+func (f *typeSetFinder) binary(op token.Token, x, y term) {
+	if len(x.ids)+len(y.ids) == 0 {
+		return
+	}
+	if op == token.SHL || op == token.SHR {
+		// The shift count may have a different type.
+		f.require(x, opsInteger)
+		f.require(y, opsInteger)
+		f.use(y)
+		return
+	}
+	ops, ok := binaryOps[op]
+	if !ok {
+		return
+	}
+	if x.foreign || y.foreign {
+		f.taint(x)
+		f.taint(y)
+		return
+	}
+	f.require(x, ops)
+	f.require(y, ops)
+	for _, t := range []term{x, y} {
+		if len(t.ids) > 0 {
+			obj := f.info.Uses[t.ids[0]]
+			f.combine(obj, x)
+			f.combine(obj, y)
+			break
+		}
+	}
+	if ops == opsEqual || ops == opsOrdered && op != token.ADD {
+		// The result is a boolean.
+		f.use(x)
+		f.use(y)
+	}
+}
+
+func (f *typeSetFinder) assignStmt(stmt *ast.AssignStmt) {
+	if len(stmt.Lhs) != len(stmt.Rhs) {
+		// The values come from a function call or the like.
+		for _, lhs := range stmt.Lhs {
+			if id, ok := lhs.(*ast.Ident); ok {
+				if obj := f.info.ObjectOf(id); f.nodes.Has(obj) {
+					f.tainted.Add(obj)
+					f.handled.Add(id)
+				}
+			}
+		}
+		return
+	}
+
+	for i, lhs := range stmt.Lhs {
+		rhs := f.term(stmt.Rhs[i])
+
+		id, ok := ast.Unparen(lhs).(*ast.Ident)
+		if !ok {
+			f.sink(rhs, f.info.TypeOf(lhs))
+			continue
+		}
+		if id.Name == "_" {
+			f.use(rhs)
+			continue
+		}
+		obj := f.info.ObjectOf(id)
+		if obj == nil {
+			continue
+		}
+		if op, ok := assignOps[stmt.Tok]; ok {
+			// An assignment operation like x += y.
+			var x term
+			if f.nodes.Has(obj) {
+				x.ids = []*ast.Ident{id}
+			} else {
+				x.foreign = true
+			}
+			f.binary(op, x, rhs)
+			f.use(rhs)
+			f.handled.Add(id)
+			continue
+		}
+		f.handled.Add(id)
+		f.assign(obj, rhs, f.info.Defs[id] != nil, false)
+	}
+}
+
+func (f *typeSetFinder) valueSpec(spec *ast.ValueSpec) {
+	for i, name := range spec.Names {
+		obj := f.info.Defs[name]
+		if obj == nil || !f.nodes.Has(obj) {
+			if i < len(spec.Values) && len(spec.Values) == len(spec.Names) && obj != nil {
+				f.sink(f.term(spec.Values[i]), obj.Type())
+			}
+			continue
+		}
+		if spec.Type != nil {
+			if len(spec.Names) > 1 {
+				// Changing the type would change it for all the names.
+				f.tainted.Add(obj)
+				continue
+			}
+			f.decls[obj] = spec.Type
+		}
+		switch {
+		case len(spec.Values) == 0:
+		case len(spec.Values) != len(spec.Names):
+			f.tainted.Add(obj)
+		default:
+			f.assign(obj, f.term(spec.Values[i]), true, spec.Type != nil)
+		}
+	}
+}
+
+func (f *typeSetFinder) returnStmt(stmt *ast.ReturnStmt) {
+	results := f.results
+	if len(stmt.Results) != results.Len() {
+		// A bare return, or a return of a function call's results.
+		if len(stmt.Results) > 0 {
+			for i := 0; i < results.Len(); i++ {
+				f.tainted.Add(results.At(i))
+			}
+		}
+		return
+	}
+	for i, expr := range stmt.Results {
+		f.assign(results.At(i), f.term(expr), false, true)
+	}
+}
+
+func (f *typeSetFinder) call(call *ast.CallExpr) {
+	tv := f.info.Types[call.Fun]
+	if tv.IsType() {
+		// A conversion.
+		if len(call.Args) != 1 {
+			return
+		}
+		arg := f.term(call.Args[0])
+		if b, ok := tv.Type.Underlying().(*types.Basic); ok && b.Info()&(types.IsInteger|types.IsFloat) != 0 {
+			f.require(arg, opsNumeric)
+			f.use(arg)
+		} else {
+			f.taint(arg)
+		}
+		return
+	}
+	if tv.IsBuiltin() {
+		return
+	}
+	sig, ok := types.Unalias(tv.Type).Underlying().(*types.Signature)
+	if !ok {
+		return
+	}
+	params := sig.Params()
+	for i, expr := range call.Args {
+		arg := f.term(expr)
+		if len(arg.ids) == 0 {
+			continue
+		}
+		switch {
+		case sig.Variadic() && i >= params.Len()-1:
+			if call.Ellipsis.IsValid() {
+				f.taint(arg)
+				continue
+			}
+			f.sink(arg, params.At(params.Len()-1).Type().(*types.Slice).Elem())
+		case i < params.Len():
+			f.sink(arg, params.At(i).Type())
+		default:
+			f.taint(arg)
+		}
+	}
+}
+
+// index records the use of idx as an index into a value of type typ.
+func (f *typeSetFinder) index(typ types.Type, idx term) {
+	if len(idx.ids) == 0 || typ == nil {
+		return
+	}
+	typ = typ.Underlying()
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem().Underlying()
+	}
+	switch typ := typ.(type) {
+	case *types.Map:
+		f.sink(idx, typ.Key())
+		return
+	case *types.Slice, *types.Array:
+	case *types.Basic:
+		if typ.Info()&types.IsString == 0 {
+			f.taint(idx)
+			return
+		}
+	default:
+		f.taint(idx)
+		return
+	}
+	f.require(idx, opsInteger)
+	f.use(idx)
+}
+
+// TypeSetNames chooses names for the type parameters that Fix adds to t.F for t.S,
+// in the same order.
+// Each is T,
+// with a numeric suffix if needed
+// to make it different from every identifier in the function
+// and from the names chosen by TypeParamNames.
+func (ch Checker) TypeSetNames(t Tuple) []string {
+	if len(t.S) == 0 {
+		return nil
+	}
+
+	taken := set.New[string]()
+	ast.Inspect(t.F, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			taken.Add(id.Name)
+		}
+		return true
+	})
+	if ch.Style == StyleGeneric {
+		for _, tp := range ch.TypeParamNames(t) {
+			taken.Add(tp)
+		}
+	}
+
+	var result []string
+	for range t.S {
+		tp := "T"
+		for n := 2; taken.Has(tp); n++ {
+			tp = "T" + strconv.Itoa(n)
+		}
+		taken.Add(tp)
+		result = append(result, tp)
+	}
+	return result
+}
@@ -0,0 +1,107 @@
+package decouple
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+func TestCheckTypeSets(t *testing.T) {
+	checker, err := NewCheckerFromDir("_testdata/typesets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker.TypeSets = true
+
+	tuples, err := checker.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		number  = "~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr | ~float32 | ~float64"
+		integer = "~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr"
+	)
+
+	cases := []struct {
+		fn   string
+		want []string // params: constraint results
+	}{
+		{fn: "Abs", want: []string{"x: " + number + " [0]"}},
+		{fn: "Max", want: []string{"a, b: cmp.Ordered [0]"}},
+		{fn: "Equal", want: []string{"a, b: comparable []"}},
+		{fn: "Concat", want: []string{"a, b: cmp.Ordered [0]"}},
+		{fn: "Half", want: []string{"x: ~float32 | ~float64 [0]"}},
+		{fn: "IsEven", want: []string{"n: " + integer + " []"}},
+		{fn: "SumSquares", want: []string{"x, y: " + number + " [0]"}},
+		{fn: "Scale", want: []string{"n: " + number + " []"}},
+		{fn: "Describe", want: []string{"n: " + number + " []"}},
+		{fn: "Shift", want: []string{"x: " + integer + " [0]", "n: " + integer + " []"}},
+		{fn: "Get", want: []string{"i: " + integer + " []"}},
+		{fn: "Sum"},
+		{fn: "Sqrt"},
+		{fn: "Large"},
+		{fn: "Identity"},
+		{fn: "Callback"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.fn, func(t *testing.T) {
+			for _, tuple := range tuples {
+				if tuple.F.Name.Name != tc.fn {
+					continue
+				}
+				var got []string
+				for _, ts := range tuple.S {
+					got = append(got, fmt.Sprintf("%s: %s %v", strings.Join(ts.Params, ", "), ts.Constraint(types.RelativeTo(tuple.P.Types)), ts.Results))
+				}
+				if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+					t.Fatalf("got %q, want %q", got, tc.want)
+				}
+				if len(tuple.S) == 0 {
+					return
+				}
+
+				errs, err := checker.Verify(tuple)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(errs) > 0 {
+					t.Errorf("got errors %v, want none", errs)
+				}
+				return
+			}
+			t.Fatalf("function %s not found", tc.fn)
+		})
+	}
+}
+
+func TestCheckTypeSetsOldGo(t *testing.T) {
+	// The go.mod file in _testdata declares Go 1.19,
+	// which has no cmp package.
+	checker, err := NewCheckerFromDir("_testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker.TypeSets = true
+
+	tuples, err := checker.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tuple := range tuples {
+		if tuple.F.Name.Name != "F77" {
+			continue
+		}
+		if len(tuple.S) != 1 {
+			t.Fatalf("got %d type sets, want 1", len(tuple.S))
+		}
+		const want = "~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr | ~float32 | ~float64 | ~string"
+		if got := tuple.S[0].Constraint(nil); got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+		return
+	}
+	t.Fatal("function F77 not found")
+}
@@ -0,0 +1,207 @@
+package decouple
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/bobg/go-generics/v3/set"
+	"golang.org/x/tools/go/packages"
+)
+
+// Upgrade is an optional interface
+// that code receiving a parameter checks for dynamically
+// (with a type assertion or type switch),
+// and that a suggested narrower type for the parameter does not include.
+// Values of the narrower type may not support it,
+// so the optional behavior (often a fast path) can be lost.
+type Upgrade struct {
+	// Interface is the optional interface type, e.g. "io.WriterTo".
+	Interface string
+
+	// Pos is the position of the type assertion or type switch case.
+	Pos token.Position
+}
+
+// String produces a description of the upgrade,
+// e.g. "narrowing loses optional interface io.WriterTo at io.go:405:15".
+func (u Upgrade) String() string {
+	return "narrowing loses optional interface " + u.Interface + " at " + u.Pos.String()
+}
+
+// maxUpgradeDepth is how many levels of calls
+// are searched for dynamic interface upgrades.
+const maxUpgradeDepth = 4
+
+// lostUpgrades finds the dynamic interface upgrades
+// that decoupling a parameter of fndecl
+// (or its elements)
+// of type typ
+// to a type with the given methods would lose.
+// The value is in the expressions for which isObj is true:
+// the parameter and its aliases.
+// It looks in fndecl itself
+// and in the functions that the value is passed to,
+// when their syntax is loaded.
+func (ch Checker) lostUpgrades(pkg *packages.Package, fndecl *ast.FuncDecl, typ types.Type, isObj func(ast.Expr) bool, methods MethodMap) []Upgrade {
+	u := upgradeFinder{
+		ch:      ch,
+		pkg:     pkg,
+		typ:     typ,
+		methods: methods,
+		seen:    set.New[types.Object](),
+		found:   set.New[string](),
+	}
+	u.find(pkg, fndecl.Body, isObj, 0)
+	return u.result
+}
+
+type upgradeFinder struct {
+	ch  Checker
+	pkg *packages.Package // the package of the function being checked
+
+	// typ is the parameter's type,
+	// and methods the methods it needs.
+	typ     types.Type
+	methods MethodMap
+
+	seen   set.Of[types.Object] // parameters of callees already searched
+	found  set.Of[string]       // interfaces already reported
+	result []Upgrade
+}
+
+// find looks for upgrades of the values for which isObj is true within body,
+// which is in pkg.
+func (u *upgradeFinder) find(pkg *packages.Package, body ast.Node, isObj func(ast.Expr) bool, depth int) {
+	info := pkg.TypesInfo
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.TypeAssertExpr:
+			if n.Type != nil && isObj(n.X) {
+				u.check(info.TypeOf(n.Type), pkg.Fset.Position(n.Type.Pos()))
+			}
+
+		case *ast.TypeSwitchStmt:
+			var assert *ast.TypeAssertExpr
+			switch stmt := n.Assign.(type) {
+			case *ast.ExprStmt:
+				assert, _ = stmt.X.(*ast.TypeAssertExpr)
+			case *ast.AssignStmt:
+				if len(stmt.Rhs) == 1 {
+					assert, _ = stmt.Rhs[0].(*ast.TypeAssertExpr)
+				}
+			}
+			if assert == nil || !isObj(assert.X) {
+				return true
+			}
+			for _, s := range n.Body.List {
+				clause, ok := s.(*ast.CaseClause)
+				if !ok {
+					continue
+				}
+				for _, expr := range clause.List {
+					u.check(info.TypeOf(expr), pkg.Fset.Position(expr.Pos()))
+				}
+			}
+
+		case *ast.CallExpr:
+			if depth >= maxUpgradeDepth {
+				return true
+			}
+			for i, arg := range n.Args {
+				if !isObj(arg) {
+					continue
+				}
+				_, fn := funcRef(info, n.Fun)
+				if fn == nil {
+					continue
+				}
+				params := fn.Type().(*types.Signature).Params()
+				if i >= params.Len() || (fn.Type().(*types.Signature).Variadic() && i >= params.Len()-1) {
+					continue
+				}
+				param := params.At(i)
+				if u.seen.Has(param) {
+					continue
+				}
+				u.seen.Add(param)
+				cpkg, cdecl := u.ch.funcDecl(fn)
+				if cdecl == nil || cdecl.Body == nil {
+					continue
+				}
+				cinfo := cpkg.TypesInfo
+				u.find(cpkg, cdecl.Body, func(expr ast.Expr) bool {
+					id, ok := ast.Unparen(expr).(*ast.Ident)
+					return ok && cinfo.ObjectOf(id) == param
+				}, depth+1)
+			}
+		}
+		return true
+	})
+}
+
+// check records typ,
+// found in a type assertion or type switch at pos,
+// if it is an optional interface that narrowing would lose.
+func (u *upgradeFinder) check(typ types.Type, pos token.Position) {
+	intf := getType[*types.Interface](typ)
+	if intf == nil || intf.NumMethods() == 0 {
+		return
+	}
+
+	var missing bool
+	for i := 0; i < intf.NumMethods(); i++ {
+		if _, ok := u.methods[intf.Method(i).Name()]; !ok {
+			missing = true
+			break
+		}
+	}
+	if !missing {
+		return
+	}
+
+	if !types.IsInterface(u.typ) && !types.Implements(u.typ, intf) {
+		// The assertion could never have succeeded for the parameter's values.
+		return
+	}
+
+	name := types.TypeString(typ, types.RelativeTo(u.pkg.Types))
+	if u.found.Has(name) {
+		return
+	}
+	u.found.Add(name)
+	u.result = append(u.result, Upgrade{Interface: name, Pos: pos})
+}
+
+// funcDecl finds the declaration of fn, and the package containing it,
+// if its syntax is loaded.
+// For a generic function, fn must be the origin (see [types.Func.Origin]).
+func (ch Checker) funcDecl(fn *types.Func) (*packages.Package, *ast.FuncDecl) {
+	fpkg := fn.Pkg()
+	if fpkg == nil {
+		return nil, nil
+	}
+	pkg, ok := ch.allPkgs[fpkg.Path()]
+	if !ok || pkg.TypesInfo == nil {
+		return nil, nil
+	}
+
+	decls, ok := ch.funcDecls[pkg]
+	if !ok {
+		decls = make(map[*types.Func]*ast.FuncDecl)
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				if fndecl, ok := decl.(*ast.FuncDecl); ok {
+					if obj, ok := pkg.TypesInfo.Defs[fndecl.Name].(*types.Func); ok {
+						decls[obj] = fndecl
+					}
+				}
+			}
+		}
+		if ch.funcDecls != nil {
+			ch.funcDecls[pkg] = decls
+		}
+	}
+	return pkg, decls[fn]
+}
@@ -0,0 +1,339 @@
+package decouple
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"github.com/bobg/errors"
+	"github.com/bobg/go-generics/v3/set"
+	"golang.org/x/tools/go/packages"
+)
+
+// InterfaceUsage is the result of checking how a named interface type
+// declared in the Checker's packages is used.
+// See Checker.CheckInterfaceUsage.
+type InterfaceUsage struct {
+	// T is the declaration of the interface type.
+	T *ast.TypeSpec
+
+	// P is the package in which T is declared.
+	P *packages.Package
+
+	// Methods is the method set of the interface type,
+	// including the methods of any interfaces it embeds.
+	Methods MethodMap
+
+	// Unused are the names of the methods in Methods
+	// that nothing in the Checker's packages calls or otherwise needs.
+	Unused []string
+
+	// Consumers are the parameters, struct fields, and package-level variables
+	// whose type is the interface type,
+	// in the order the Checker's packages declare them.
+	Consumers []Consumer
+}
+
+// Pos computes the filename and offset
+// of the name of the interface type in u.
+func (u InterfaceUsage) Pos() token.Position {
+	return u.P.Fset.Position(u.T.Name.Pos())
+}
+
+// Consumer is a parameter, struct field, or package-level variable
+// of an interface type,
+// and the methods of the interface it needs.
+type Consumer struct {
+	Kind ConsumerKind
+
+	// Name is the name of the parameter, field, or variable.
+	Name string
+
+	// Context is the name of the function declaring a parameter,
+	// or of the struct type declaring a field.
+	// It is empty for a variable.
+	Context string
+
+	Pos token.Position
+
+	// Methods are the methods of the interface that the consumer needs.
+	// When that cannot be determined
+	// (e.g. because the consumer is compared to a concrete value)
+	// this is all of them.
+	Methods MethodMap
+}
+
+// ConsumerKind is the type of Consumer.Kind.
+type ConsumerKind int
+
+// Values for ConsumerKind.
+const (
+	ConsumerParam ConsumerKind = iota
+	ConsumerField
+	ConsumerVar
+)
+
+// CheckInterfaceUsage checks how the named interface types
+// declared in the Checker's packages are used,
+// looking for interfaces wider than their consumers need:
+// ones with methods nothing calls,
+// or with consumers needing only a few of their methods.
+// The result is a list of InterfaceUsages,
+// one for each non-generic interface type with methods,
+// in the order of their declarations.
+//
+// A consumer is analyzed like a function parameter
+// (normally after Check has run, as with CheckFields).
+// A method is unused if no consumer needs it,
+// it is never called or referred to
+// (as in s.Close() or Store.Close)
+// on a value of the interface type or one embedding it,
+// and no value of the interface type
+// is used as another interface type that includes it.
+// Uses outside the Checker's packages cannot be seen,
+// so methods of exported interfaces may be needed in ways this does not account for.
+func (ch Checker) CheckInterfaceUsage() (_ []InterfaceUsage, err error) {
+	defer recoverDerr(&err)
+
+	var (
+		result []InterfaceUsage
+		index  = make(map[*types.TypeName]int) // interface types -> their indexes in result
+	)
+	for _, pkg := range ch.pkgs {
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				gendecl, ok := decl.(*ast.GenDecl)
+				if !ok || gendecl.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gendecl.Specs {
+					tspec, ok := spec.(*ast.TypeSpec)
+					if !ok || tspec.Assign.IsValid() || tspec.TypeParams != nil {
+						continue
+					}
+					if _, ok := tspec.Type.(*ast.InterfaceType); !ok {
+						continue
+					}
+					tn, ok := pkg.TypesInfo.Defs[tspec.Name].(*types.TypeName)
+					if !ok {
+						continue
+					}
+					intf, ok := tn.Type().Underlying().(*types.Interface)
+					if !ok || !intf.IsMethodSet() || intf.NumMethods() == 0 {
+						continue
+					}
+					mm := make(MethodMap)
+					addMethodsToMap(intf, mm)
+					index[tn] = len(result)
+					result = append(result, InterfaceUsage{T: tspec, P: pkg, Methods: mm})
+				}
+			}
+		}
+	}
+
+	// usageFor finds the InterfaceUsage for typ, if it has one.
+	usageFor := func(typ types.Type) *InterfaceUsage {
+		named, ok := types.Unalias(typ).(*types.Named)
+		if !ok {
+			return nil
+		}
+		i, ok := index[named.Obj()]
+		if !ok {
+			return nil
+		}
+		return &result[i]
+	}
+
+	// Consumers that are parameters.
+	for _, pkg := range ch.pkgs {
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				fndecl, ok := decl.(*ast.FuncDecl)
+				if !ok || fndecl.Body == nil {
+					continue
+				}
+				for _, field := range fndecl.Type.Params.List {
+					for _, name := range field.Names {
+						if name.Name == "_" {
+							continue
+						}
+						obj := pkg.TypesInfo.Defs[name]
+						if obj == nil {
+							continue
+						}
+						u := usageFor(obj.Type())
+						if u == nil {
+							continue
+						}
+						res, err := ch.checkParamEngine(pkg, fndecl, name, obj)
+						if err != nil {
+							return nil, errors.Wrapf(err, "analyzing parameter %s of %s", name.Name, fndecl.Name.Name)
+						}
+						u.Consumers = append(u.Consumers, Consumer{
+							Kind:    ConsumerParam,
+							Name:    name.Name,
+							Context: fndecl.Name.Name,
+							Pos:     pkg.Fset.Position(name.Pos()),
+							Methods: neededMethods(res, u.Methods),
+						})
+					}
+				}
+			}
+		}
+	}
+
+	// Consumers that are struct fields and package-level variables.
+	var (
+		names    = make(map[types.Object]*ast.Ident)
+		ordered  []types.Object
+		contexts = make(map[types.Object]string) // struct type names for fields
+	)
+	for _, pkg := range ch.pkgs {
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				gendecl, ok := decl.(*ast.GenDecl)
+				if !ok {
+					continue
+				}
+				for _, spec := range gendecl.Specs {
+					var (
+						idents  []*ast.Ident
+						context string
+					)
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						st, ok := spec.Type.(*ast.StructType)
+						if !ok {
+							continue
+						}
+						for _, field := range st.Fields.List {
+							idents = append(idents, field.Names...)
+						}
+						context = spec.Name.Name
+
+					case *ast.ValueSpec:
+						if gendecl.Tok == token.VAR {
+							idents = spec.Names
+						}
+					}
+					for _, id := range idents {
+						if id.Name == "_" {
+							continue
+						}
+						obj := pkg.TypesInfo.Defs[id]
+						if obj == nil || usageFor(obj.Type()) == nil {
+							continue
+						}
+						names[obj] = id
+						ordered = append(ordered, obj)
+						if context != "" {
+							contexts[obj] = context
+						}
+					}
+				}
+			}
+		}
+	}
+	uses := ch.declsUsing(names)
+	for _, obj := range ordered {
+		u := usageFor(obj.Type())
+		kind := ConsumerVar
+		if v, ok := obj.(*types.Var); ok && v.IsField() {
+			kind = ConsumerField
+		}
+		u.Consumers = append(u.Consumers, Consumer{
+			Kind:    kind,
+			Name:    obj.Name(),
+			Context: contexts[obj],
+			Pos:     ch.position(obj.Pkg(), obj.Pos()),
+			Methods: neededMethods(ch.checkUses(obj, uses[obj]), u.Methods),
+		})
+	}
+
+	// Methods called, referred to,
+	// or needed by other interface types that values flow into.
+	var (
+		called = set.New[*types.Func]()
+		needed = make(map[*InterfaceUsage]set.Of[string])
+	)
+	for _, pkg := range ch.pkgs {
+		for _, sel := range pkg.TypesInfo.Selections {
+			if fn, ok := sel.Obj().(*types.Func); ok && sel.Kind() != types.FieldVal {
+				called.Add(fn)
+			}
+		}
+		flows(pkg, func(dst types.Type, src ast.Expr) {
+			srcType := pkg.TypesInfo.TypeOf(src)
+			if srcType == nil || types.Identical(dst, srcType) {
+				return
+			}
+			u := usageFor(srcType)
+			if u == nil {
+				return
+			}
+			intf := getType[*types.Interface](dst)
+			if intf == nil {
+				return
+			}
+			if needed[u] == nil {
+				needed[u] = set.New[string]()
+			}
+			for i := 0; i < intf.NumMethods(); i++ {
+				needed[u].Add(intf.Method(i).Name())
+			}
+		})
+	}
+
+	for i := range result {
+		u := &result[i]
+		sort.SliceStable(u.Consumers, func(i, j int) bool {
+			return PositionLess(u.Consumers[i].Pos, u.Consumers[j].Pos)
+		})
+
+		intf := u.T.Name
+		tn := u.P.TypesInfo.Defs[intf].(*types.TypeName)
+		iface := tn.Type().Underlying().(*types.Interface)
+		for j := 0; j < iface.NumMethods(); j++ {
+			m := iface.Method(j)
+			if called.Has(m) || needed[u].Has(m.Name()) {
+				continue
+			}
+			var used bool
+			for _, c := range u.Consumers {
+				if _, ok := c.Methods[m.Name()]; ok {
+					used = true
+					break
+				}
+			}
+			if !used {
+				u.Unused = append(u.Unused, m.Name())
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// neededMethods is the subset of all, an interface's methods,
+// that a consumer of the interface with the result res needs.
+func neededMethods(res ParamResult, all MethodMap) MethodMap {
+	if len(res.Methods) > 0 {
+		return res.Methods
+	}
+	if res.Reason != nil && res.Reason.Kind == ReasonNoMethods {
+		return MethodMap{}
+	}
+	return all
+}
+
+// position finds the position of pos,
+// in tpkg, among the Checker's packages.
+func (ch Checker) position(tpkg *types.Package, pos token.Pos) token.Position {
+	for _, pkg := range ch.pkgs {
+		if pkg.Types == tpkg {
+			return pkg.Fset.Position(pos)
+		}
+	}
+	return token.Position{}
+}
@@ -0,0 +1,73 @@
+package decouple
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/bobg/go-generics/v3/maps"
+)
+
+func TestCheckInterfaceUsage(t *testing.T) {
+	checker, err := NewCheckerFromDir("_testdata/segregation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checker.Check(); err != nil {
+		t.Fatal(err)
+	}
+	usages, err := checker.CheckInterfaceUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]InterfaceUsage)
+	for _, u := range usages {
+		got[u.T.Name.Name] = u
+	}
+
+	cases := []struct {
+		intf      string
+		unused    []string
+		consumers map[string]string // "Context.Name" -> sorted methods
+	}{{
+		intf:   "Store",
+		unused: []string{"Stats"},
+		consumers: map[string]string{
+			"Lookup.s":      "[Get]",
+			"Copy.dst":      "[Put]",
+			"Copy.src":      "[Get Keys]",
+			"shutdown.s":    "[Close]",
+			"describe.s":    "[]",
+			"Server.store":  "[Get Put]",
+			".defaultStore": "[Delete]",
+		},
+	}, {
+		intf: "Logger",
+		consumers: map[string]string{
+			"report.l": "[Flush Logf]",
+		},
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.intf, func(t *testing.T) {
+			u, ok := got[tc.intf]
+			if !ok {
+				t.Fatalf("interface %s not found", tc.intf)
+			}
+			if !reflect.DeepEqual(u.Unused, tc.unused) {
+				t.Errorf("got unused %v, want %v", u.Unused, tc.unused)
+			}
+			gotConsumers := make(map[string]string)
+			for _, c := range u.Consumers {
+				methods := maps.Keys(c.Methods)
+				sort.Strings(methods)
+				gotConsumers[c.Context+"."+c.Name] = fmt.Sprint(methods)
+			}
+			if !reflect.DeepEqual(gotConsumers, tc.consumers) {
+				t.Errorf("got consumers %v, want %v", gotConsumers, tc.consumers)
+			}
+		})
+	}
+}
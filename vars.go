@@ -0,0 +1,113 @@
+package decouple
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// VarTuple is the result of checking the package-level variables
+// declared in one var spec.
+// See Checker.CheckVars.
+type VarTuple struct {
+	// V is the declaration of the variables.
+	V *ast.ValueSpec
+
+	// P is the package in which the declaration appears.
+	P *packages.Package
+
+	// M is a map from the names of variables eligible for decoupling
+	// to MethodMaps for each such variable.
+	M map[string]MethodMap
+
+	// Vars maps the name of every variable in V checked
+	// to the detailed result of checking it.
+	// For variables not eligible for decoupling,
+	// this includes the reason.
+	Vars map[string]ParamResult
+}
+
+// Pos computes the filename and offset
+// of the first variable name in t.
+func (t VarTuple) Pos() token.Position {
+	return t.P.Fset.Position(t.V.Names[0].Pos())
+}
+
+// CheckVars checks the package-level variables
+// declared in the Checker's packages,
+// looking for variables with concrete types that could be interfaces instead,
+// like a *http.Client used only for its Do method.
+// The result is a list of VarTuples,
+// one for each var spec,
+// in the order of their declarations.
+//
+// A variable is analyzed like a function parameter,
+// in every declaration in the Checker's packages that refers to it,
+// so an exported variable may be used outside those packages
+// in ways this does not account for.
+// As with CheckFields,
+// a variable passed to a function is eligible
+// only if that function's parameter has already been found eligible
+// (normally by Check),
+// and only variables of pointer, function, and interface types are eligible,
+// not ones whose types have usable zero values,
+// like a strings.Builder.
+// This always uses EngineSyntax.
+func (ch Checker) CheckVars() (_ []VarTuple, err error) {
+	defer recoverDerr(&err)
+
+	var (
+		result []VarTuple
+		names  = make(map[types.Object]*ast.Ident)
+	)
+	for _, pkg := range ch.pkgs {
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				gendecl, ok := decl.(*ast.GenDecl)
+				if !ok || gendecl.Tok != token.VAR {
+					continue
+				}
+				for _, spec := range gendecl.Specs {
+					valspec, ok := spec.(*ast.ValueSpec)
+					if !ok {
+						continue
+					}
+					for _, name := range valspec.Names {
+						if name.Name == "_" {
+							continue
+						}
+						if obj := pkg.TypesInfo.Defs[name]; obj != nil {
+							names[obj] = name
+						}
+					}
+					result = append(result, VarTuple{V: valspec, P: pkg})
+				}
+			}
+		}
+	}
+
+	uses := ch.declsUsing(names)
+
+	var nonempty []VarTuple
+	for _, t := range result {
+		t.M = make(map[string]MethodMap)
+		t.Vars = make(map[string]ParamResult)
+		for _, name := range t.V.Names {
+			obj := t.P.TypesInfo.Defs[name]
+			if names[obj] != name {
+				continue
+			}
+			res := ch.checkUses(obj, uses[obj])
+			t.Vars[name.Name] = res
+			if len(res.Methods) > 0 {
+				t.M[name.Name] = res.Methods
+			}
+		}
+		if len(t.Vars) > 0 {
+			nonempty = append(nonempty, t)
+		}
+	}
+	return nonempty, nil
+}
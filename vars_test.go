@@ -0,0 +1,42 @@
+package decouple
+
+import "testing"
+
+func TestCheckVars(t *testing.T) {
+	checker, err := NewCheckerFromDir("_testdata/vars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vars, err := checker.CheckVars()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		want string // see testResult
+	}{
+		{name: "client", want: "[Do]"},
+		{name: "logger", want: "[Print Printf]"},
+		{name: "out", want: "[Write]"},
+		{name: "errs", want: "returned as concrete type"},
+		{name: "rw", want: "[Write]"},
+		{name: "debug", want: "used as a boolean condition"},
+		{name: "defaultLimit", want: "no methods used"},
+		{name: "sb", want: "zero value of strings.Builder is usable"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, vt := range vars {
+				res, ok := vt.Vars[tc.name]
+				if !ok {
+					continue
+				}
+				testResult(t, res, tc.want)
+				return
+			}
+			t.Fatalf("variable %s not found", tc.name)
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package decouple
+
+import (
+	"go/ast"
+	"os"
+	"path/filepath"
+
+	"github.com/bobg/errors"
+	"github.com/bobg/go-generics/v3/set"
+	"golang.org/x/tools/go/packages"
+)
+
+// verifyMode is the packages.Load mode used by Checker.Verify.
+// Only the packages being verified are type-checked from source;
+// their dependencies come from export data.
+const verifyMode = packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo
+
+// Verify checks that the changes Checker.Fix would make for t
+// leave the code in a compilable state.
+// It applies the changes to in-memory copies of the affected files
+// (via the Overlay field of "golang.org/x/go/packages".Config),
+// then reloads and type-checks the affected packages
+// and every package in the Checker that imports them, directly or indirectly,
+// along with their tests.
+//
+// When a suggestion in t depends on decoupling the parameter of a function it calls
+// (see ParamResult.Requires),
+// the changes for that function are applied too.
+//
+// The result is the list of new type errors
+// (not counting any that were present before the change).
+// It is empty if the changes are sound.
+// The error return is for problems performing the verification itself.
+func (ch Checker) Verify(t Tuple) ([]packages.Error, error) {
+	var (
+		overlay = make(map[string][]byte)
+		byFile  = make(map[string][]Tuple)
+		files   []string
+	)
+	for _, tt := range ch.withRequired(t) {
+		filename := tt.Pos().Filename
+		if _, ok := byFile[filename]; !ok {
+			files = append(files, filename)
+		}
+		byFile[filename] = append(byFile[filename], tt)
+	}
+	for _, filename := range files {
+		fix, err := ch.Fix(byFile[filename]...)
+		if err != nil {
+			return nil, errors.Wrap(err, "computing changes")
+		}
+		if len(fix.Edits) == 0 {
+			continue
+		}
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", filename)
+		}
+		out, err := fix.Apply(src)
+		if err != nil {
+			return nil, errors.Wrapf(err, "changing %s", filename)
+		}
+		overlay[filename] = out
+	}
+	if len(overlay) == 0 {
+		return nil, nil
+	}
+
+	var (
+		patterns []string
+		seen     = set.New[*packages.Package]()
+		before   = set.New[string]()
+	)
+	for _, filename := range files {
+		for _, pkg := range ch.importers(byFile[filename][0].P) {
+			if seen.Has(pkg) {
+				continue
+			}
+			seen.Add(pkg)
+			patterns = append(patterns, pkg.PkgPath)
+			for _, e := range pkg.Errors {
+				before.Add(e.Error())
+			}
+		}
+	}
+
+	conf := &packages.Config{
+		Mode:    verifyMode,
+		Dir:     filepath.Dir(files[0]),
+		Overlay: overlay,
+		Tests:   true, // Tests can be broken by the changes too.
+	}
+	pkgs, err := packages.Load(conf, patterns...)
+	if err != nil {
+		return nil, errors.Wrap(err, "reloading packages")
+	}
+
+	var result []packages.Error
+	for _, pkg := range pkgs {
+		for _, e := range pkg.Errors {
+			// Each error in a package is also in its test variant.
+			if !before.Has(e.Error()) {
+				result = append(result, e)
+				before.Add(e.Error())
+			}
+		}
+	}
+	return result, nil
+}
+
+// withRequired returns t
+// plus a Tuple for each function with parameters that t's suggestions require,
+// directly or indirectly.
+func (ch Checker) withRequired(t Tuple) []Tuple {
+	var (
+		result  = []Tuple{t}
+		indexes = make(map[*ast.FuncDecl]int) // function declarations -> their tuples in result
+	)
+	if t.L == nil {
+		indexes[t.F] = 0
+	}
+
+	for i := 0; i < len(result); i++ {
+		tt := result[i]
+		for param := range tt.M {
+			for _, ref := range tt.Params[param].Requires {
+				if ref.F == nil {
+					continue
+				}
+				summary, ok := ch.summaryFor(ref)
+				if !ok {
+					continue
+				}
+				j, ok := indexes[ref.F]
+				if !ok {
+					j = len(result)
+					indexes[ref.F] = j
+					result = append(result, Tuple{
+						F:      ref.F,
+						P:      ref.P,
+						M:      make(map[string]MethodMap),
+						Params: make(map[string]ParamResult),
+					})
+				}
+				if _, ok := result[j].M[ref.Name]; ok {
+					continue
+				}
+				result[j].M[ref.Name] = summary.res.Methods
+				result[j].Params[ref.Name] = summary.res
+			}
+		}
+	}
+
+	return result
+}
+
+// summaryFor finds the recorded result for the parameter denoted by ref.
+func (ch Checker) summaryFor(ref ParamRef) (paramSummary, bool) {
+	for _, field := range ref.F.Type.Params.List {
+		for _, name := range field.Names {
+			if name.Name != ref.Name {
+				continue
+			}
+			obj, ok := ref.P.TypesInfo.Defs[name]
+			if !ok {
+				return paramSummary{}, false
+			}
+			summary, ok := ch.summaries[obj]
+			return summary, ok
+		}
+	}
+	return paramSummary{}, false
+}
+
+// importers returns pkg
+// plus every package in the Checker that imports it, directly or indirectly.
+func (ch Checker) importers(pkg *packages.Package) []*packages.Package {
+	var (
+		result = []*packages.Package{pkg}
+		memo   = map[*packages.Package]bool{pkg: true}
+	)
+
+	var imports func(*packages.Package) bool
+	imports = func(p *packages.Package) bool {
+		if res, ok := memo[p]; ok {
+			return res
+		}
+		memo[p] = false // in case of cycles
+		for _, ipkg := range p.Imports {
+			if imports(ipkg) {
+				memo[p] = true
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, p := range ch.pkgs {
+		if p != pkg && imports(p) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
@@ -0,0 +1,112 @@
+package decouple
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	checker, err := NewCheckerFromDir("_testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tuples, err := checker.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var f7 Tuple
+	for _, tuple := range tuples {
+		if tuple.F.Name.Name == "F7" {
+			f7 = tuple
+			break
+		}
+	}
+	if f7.F == nil {
+		t.Fatal("F7 not found")
+	}
+
+	errs, err := checker.Verify(f7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) > 0 {
+		t.Errorf("got errors %v, want none", errs)
+	}
+
+	// Now pretend rc needs only Read, not Close.
+	rc := f7.M["rc"]
+	f7.M = map[string]MethodMap{"rc": {"Read": rc["Read"]}}
+
+	errs, err = checker.Verify(f7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) == 0 {
+		t.Fatal("got no errors, want some")
+	}
+	if !strings.Contains(errs[0].Msg, "Close") {
+		t.Errorf("got error %s, want one mentioning Close", errs[0])
+	}
+}
+
+func TestVerifyRequired(t *testing.T) {
+	checker, err := NewCheckerFromDir("_testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tuples, err := checker.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fn := range []string{"F50", "A"} {
+		t.Run(fn, func(t *testing.T) {
+			for _, tuple := range tuples {
+				if tuple.F.Name.Name != fn {
+					continue
+				}
+				if got := len(checker.withRequired(tuple)); got != 3 {
+					t.Errorf("got %d tuples with required ones, want 3", got)
+				}
+				errs, err := checker.Verify(tuple)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(errs) > 0 {
+					t.Errorf("got errors %v, want none", errs)
+				}
+				return
+			}
+			t.Fatalf("%s not found", fn)
+		})
+	}
+}
+
+func TestVerifyTests(t *testing.T) {
+	checker, err := NewCheckerFromDir("_testdata/verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tuples, err := checker.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tuples) != 1 || len(tuples[0].M) != 1 {
+		t.Fatalf("got %d tuples, want 1 with a suggestion", len(tuples))
+	}
+
+	errs, err := checker.Verify(tuples[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range errs {
+		if strings.Contains(e.Pos, "verify_test.go") {
+			return
+		}
+	}
+	t.Errorf("got errors %v, want one in verify_test.go", errs)
+}